@@ -0,0 +1,56 @@
+package randomness
+
+import (
+	"io"
+	"math/rand/v2"
+)
+
+// NewReader adapts a Randomness to an io.Reader. Each Read fills p with the
+// next len(p) bytes of the stream, exactly as Bytes(len(p)) would, and never
+// returns io.EOF.
+func NewReader(r Randomness) io.Reader {
+	return &reader{r: r}
+}
+
+type reader struct {
+	r Randomness
+}
+
+func (rd *reader) Read(p []byte) (int, error) {
+	b, err := rd.r.Bytes(len(p))
+	if err != nil {
+		return 0, err
+	}
+	return copy(p, b), nil
+}
+
+// Source adapts a Randomness to math/rand/v2.Source, so algorithms built on
+// math/rand/v2 can be made verifiable. Each call to Uint64 consumes exactly
+// eight bytes of the stream, exactly as Randomness.Uint64 does. How a
+// math/rand/v2.Rand consumes its Source is defined by the Go release, so
+// prefer the methods of Randomness where one exists.
+type Source struct {
+	r Randomness
+}
+
+var _ rand.Source = (*Source)(nil)
+
+// NewSource creates a Source reading from r.
+func NewSource(r Randomness) *Source {
+	return &Source{r: r}
+}
+
+// Uint64 returns the next uint64 of the stream. rand.Source cannot return an
+// error, so Uint64 panics if the Randomness does.
+func (s *Source) Uint64() uint64 {
+	u, err := s.r.Uint64()
+	if err != nil {
+		panic(err)
+	}
+	return u
+}
+
+// Rand returns a math/rand/v2.Rand drawing from the Source.
+func (s *Source) Rand() *rand.Rand {
+	return rand.New(s)
+}
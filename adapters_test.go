@@ -0,0 +1,42 @@
+package randomness
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestNewReader(t *testing.T) {
+	buf := make([]byte, 100)
+	n, err := io.ReadFull(NewReader(NewRandomness(BetaBytes("test"))), buf)
+	if err != nil || n != len(buf) {
+		t.Fatalf("ReadFull() = %d, %v", n, err)
+	}
+
+	expected, _ := NewRandomness(BetaBytes("test")).Bytes(100)
+	if !bytes.Equal(buf, expected) {
+		t.Errorf("Read() = %x, want %x", buf, expected)
+	}
+}
+
+func TestNewSource(t *testing.T) {
+	r := NewRandomness(BetaBytes("test"))
+	control := NewRandomness(BetaBytes("test"))
+
+	source := NewSource(r)
+	for range 10 {
+		want, _ := control.Uint64()
+		if got := source.Uint64(); got != want {
+			t.Errorf("Uint64() = %d, want %d", got, want)
+		}
+	}
+
+	// The same stream always drives math/rand/v2 the same way.
+	a := NewSource(NewRandomness(BetaBytes("test"))).Rand().Perm(10)
+	b := NewSource(NewRandomness(BetaBytes("test"))).Rand().Perm(10)
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("Perm() = %v and %v from the same stream", a, b)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package randomness
+
+import (
+	"encoding/hex"
+	"slices"
+	"testing"
+)
+
+// golden pins the output of an algorithm version for the beta "test". The
+// draws are made in field order from a single Randomness. These values must
+// never change once a version is released.
+type golden struct {
+	bytes        string // Bytes(80), crossing two amplifications
+	pick         []int  // Pick(5, 37)
+	pickDistinct []int  // PickDistinct(5, 52)
+	intN         int    // IntN(6)
+	probability  float64
+	selection    []goldenSelection // Selection of 4 from goldenItems
+	permutation  []int             // Permutation(10)
+}
+
+type goldenSelection struct {
+	value    string
+	instance int
+}
+
+func goldenItems() []Item {
+	return []Item{
+		NewGenericItem("a", 1, 3),
+		NewGenericItem("b", 2.5, 2),
+		NewGenericItem("c", 0.5, -1),
+	}
+}
+
+const goldenBytes = "746573743c986704c87a71db3e6958926ab8a0b2bf2f31346b89fcda80eb993ccb89edba1b765fea0e0700804010175ad9d4d240e22369ee2979e5908f4607fc2e242d2402aece2bc998340c8b8c71f7"
+
+var goldenVectors = map[Algorithm]golden{
+	AlgorithmV1: {
+		bytes:        goldenBytes,
+		pick:         []int{12, 35, 13, 2, 19},
+		pickDistinct: []int{49, 1, 18, 42, 3},
+		intN:         1,
+		probability:  0.601919305681934,
+		selection:    []goldenSelection{{"a", 1}, {"b", 2}, {"a", 2}, {"b", 1}},
+		permutation:  []int{7, 9, 4, 6, 1, 3, 8, 0, 2, 5},
+	},
+	AlgorithmV2: {
+		bytes:        goldenBytes,
+		pick:         []int{22, 15, 5, 10, 9},
+		pickDistinct: []int{0, 33, 25, 42, 7},
+		intN:         5,
+		probability:  0.6851681659830914,
+		selection:    []goldenSelection{{"b", 2}, {"a", 3}, {"b", 1}, {"a", 2}},
+		permutation:  []int{3, 8, 7, 4, 5, 9, 0, 6, 1, 2},
+	},
+	AlgorithmV3: {
+		bytes:        goldenBytes,
+		pick:         []int{22, 15, 5, 10, 9},
+		pickDistinct: []int{0, 33, 25, 42, 7},
+		intN:         5,
+		probability:  0.6851681659830914,
+		selection:    []goldenSelection{{"b", 2}, {"a", 3}, {"b", 1}, {"a", 2}},
+		permutation:  []int{3, 8, 7, 4, 5, 9, 0, 6, 1, 2},
+	},
+	AlgorithmV4: {
+		bytes:        goldenBytes,
+		pick:         []int{22, 15, 5, 10, 9},
+		pickDistinct: []int{0, 33, 26, 42, 10},
+		intN:         5,
+		probability:  0.6851681659830914,
+		selection:    []goldenSelection{{"b", 2}, {"a", 3}, {"b", 1}, {"a", 2}},
+		permutation:  []int{3, 8, 7, 4, 5, 9, 0, 6, 1, 2},
+	},
+}
+
+func TestGoldenVectors(t *testing.T) {
+	for v := AlgorithmV1; v <= AlgorithmLatest; v++ {
+		want, ok := goldenVectors[v]
+		if !ok {
+			t.Errorf("no golden vectors for algorithm %s", v)
+			continue
+		}
+		t.Run(v.String(), func(t *testing.T) {
+			r, err := NewRandomnessV(v, BetaBytes("test"))
+			if err != nil {
+				t.Fatalf("NewRandomnessV() error = %v", err)
+			}
+			if r.Algorithm() != v {
+				t.Errorf("Algorithm() = %s, want %s", r.Algorithm(), v)
+			}
+
+			b, err := r.Bytes(80)
+			if err != nil {
+				t.Fatalf("Bytes() error = %v", err)
+			}
+			if got := hex.EncodeToString(b); got != want.bytes {
+				t.Errorf("Bytes(80) = %s, want %s", got, want.bytes)
+			}
+
+			pick, err := r.Pick(5, 37)
+			if err != nil {
+				t.Fatalf("Pick() error = %v", err)
+			}
+			if !slices.Equal(pick, want.pick) {
+				t.Errorf("Pick(5, 37) = %v, want %v", pick, want.pick)
+			}
+
+			distinct, err := r.PickDistinct(5, 52)
+			if err != nil {
+				t.Fatalf("PickDistinct() error = %v", err)
+			}
+			if !slices.Equal(distinct, want.pickDistinct) {
+				t.Errorf("PickDistinct(5, 52) = %v, want %v", distinct, want.pickDistinct)
+			}
+
+			n, err := r.IntN(6)
+			if err != nil {
+				t.Fatalf("IntN() error = %v", err)
+			}
+			if n != want.intN {
+				t.Errorf("IntN(6) = %d, want %d", n, want.intN)
+			}
+
+			p, err := r.Probability()
+			if err != nil {
+				t.Fatalf("Probability() error = %v", err)
+			}
+			if p != want.probability {
+				t.Errorf("Probability() = %v, want %v", p, want.probability)
+			}
+
+			results, err := r.Selection(SelectionConfig{Items: goldenItems(), Count: 4})
+			if err != nil {
+				t.Fatalf("Selection() error = %v", err)
+			}
+			var got []goldenSelection
+			for _, result := range results {
+				got = append(got, goldenSelection{result.Any().(string), result.Instance()})
+			}
+			if !slices.Equal(got, want.selection) {
+				t.Errorf("Selection() = %v, want %v", got, want.selection)
+			}
+
+			permutation, err := Permutation(r, 10)
+			if err != nil {
+				t.Fatalf("Permutation() error = %v", err)
+			}
+			if !slices.Equal(permutation, want.permutation) {
+				t.Errorf("Permutation(10) = %v, want %v", permutation, want.permutation)
+			}
+		})
+	}
+}
+
+func TestNewRandomnessV(t *testing.T) {
+	if _, err := NewRandomnessV(0, BetaBytes("test")); err == nil {
+		t.Error("NewRandomnessV(0) did not return an error")
+	}
+	if _, err := NewRandomnessV(AlgorithmLatest+1, BetaBytes("test")); err == nil {
+		t.Error("NewRandomnessV(AlgorithmLatest+1) did not return an error")
+	}
+	if NewRandomness(BetaBytes("test")).Algorithm() != AlgorithmV1 {
+		t.Error("NewRandomness() does not use AlgorithmV1")
+	}
+}
+
+func TestParseAlgorithm(t *testing.T) {
+	for v := AlgorithmV1; v <= AlgorithmLatest; v++ {
+		got, err := ParseAlgorithm(v.String())
+		if err != nil || got != v {
+			t.Errorf("ParseAlgorithm(%q) = %s, %v", v.String(), got, err)
+		}
+	}
+	for _, s := range []string{"", "v", "v0", "1", "v01", "v1x"} {
+		if _, err := ParseAlgorithm(s); err == nil {
+			t.Errorf("ParseAlgorithm(%q) did not return an error", s)
+		}
+	}
+}
+
+func TestPickDistinctSparse(t *testing.T) {
+	r, _ := NewRandomnessV(AlgorithmV4, BetaBytes("test"))
+	picked, err := r.PickDistinct(5, 1_000_000_000)
+	if err != nil {
+		t.Fatalf("PickDistinct() error = %v", err)
+	}
+	seen := make(map[int]bool)
+	for _, v := range picked {
+		if v < 0 || v >= 1_000_000_000 || seen[v] {
+			t.Errorf("PickDistinct() = %v, want distinct values in range", picked)
+		}
+		seen[v] = true
+	}
+
+	// Picking the whole range is a permutation of it.
+	all, err := r.PickDistinct(100, 100)
+	if err != nil {
+		t.Fatalf("PickDistinct() error = %v", err)
+	}
+	sorted := slices.Sorted(slices.Values(all))
+	for i, v := range sorted {
+		if v != i {
+			t.Fatalf("PickDistinct(100, 100) = %v, want a permutation", all)
+		}
+	}
+
+	// The sparse shuffle consumes the same bytes as the dense walk.
+	for _, sampling := range []Sampling{SamplingAccumulator, SamplingRejection} {
+		v3, _ := NewRandomnessV(AlgorithmV3, BetaBytes("test"), WithSampling(sampling))
+		v4, _ := NewRandomnessV(AlgorithmV4, BetaBytes("test"), WithSampling(sampling))
+		v3.PickDistinct(10, 1000)
+		v4.PickDistinct(10, 1000)
+		a, _ := v3.Uint64()
+		b, _ := v4.Uint64()
+		if a != b {
+			t.Errorf("PickDistinct() with %s sampling consumed different bytes in v3 and v4", sampling)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package randomness
+
+import (
+	"crypto/hkdf"
+	"crypto/sha256"
+	"crypto/sha3"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+	"sync"
+)
+
+// Amplifier extends a beta into an unbounded stream of bytes. The stream of
+// a Randomness is the beta itself followed by Block(β, 1), Block(β, 2) and so
+// on.
+type Amplifier interface {
+	// ID identifies the construction so that verifiers know which one to
+	// use. It must be unique among registered amplifiers.
+	ID() string
+
+	// Block returns the counter-th block of the expansion of β. Counters
+	// start at 1. Every block must have the same length, so the stream can
+	// be entered at any block. Block must not modify β.
+	Block(β []byte, counter uint64) []byte
+}
+
+// The built-in amplifiers. Each produces 64 byte blocks.
+var (
+	// AmplifierSHA512 computes SHA-512(β || counter) with the counter as a
+	// big-endian uint64. It is used by every algorithm version by default.
+	AmplifierSHA512 Amplifier = sha512Amplifier{}
+
+	// AmplifierHKDFSHA256 computes HKDF-SHA256 with β as the secret, no salt
+	// and the info "randomness/hkdf-sha256" || counter, with the counter as
+	// a big-endian uint64.
+	AmplifierHKDFSHA256 Amplifier = hkdfSHA256Amplifier{}
+
+	// AmplifierSHAKE256 reads 64 bytes of the SHAKE256 XOF over
+	// β || counter, with the counter as a big-endian uint64.
+	AmplifierSHAKE256 Amplifier = shake256Amplifier{}
+
+	// AmplifierChaCha20 is the RFC 8439 ChaCha20 keystream keyed with
+	// SHA-256(β). Block n is keystream block n-1: the low 32 bits of n-1
+	// are the block counter and the high 32 bits are the first word of the
+	// otherwise zero nonce.
+	AmplifierChaCha20 Amplifier = chaCha20Amplifier{}
+)
+
+var (
+	amplifiersMu sync.RWMutex
+	amplifiers   = map[string]Amplifier{}
+)
+
+func init() {
+	for _, a := range []Amplifier{AmplifierSHA512, AmplifierHKDFSHA256, AmplifierSHAKE256, AmplifierChaCha20} {
+		if err := RegisterAmplifier(a); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// RegisterAmplifier makes a custom amplifier available to AmplifierByID. It
+// is safe to call concurrently with AmplifierByID.
+func RegisterAmplifier(a Amplifier) error {
+	amplifiersMu.Lock()
+	defer amplifiersMu.Unlock()
+	if _, ok := amplifiers[a.ID()]; ok {
+		return fmt.Errorf("amplifier %q is already registered", a.ID())
+	}
+	amplifiers[a.ID()] = a
+	return nil
+}
+
+// AmplifierByID returns the registered amplifier with the given identifier.
+func AmplifierByID(id string) (Amplifier, error) {
+	amplifiersMu.RLock()
+	a, ok := amplifiers[id]
+	amplifiersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown amplifier %q", id)
+	}
+	return a, nil
+}
+
+type sha512Amplifier struct{}
+
+func (sha512Amplifier) ID() string {
+	return "sha512"
+}
+
+func (sha512Amplifier) Block(β []byte, counter uint64) []byte {
+	sha := sha512.New()
+	sha.Write(β)
+	sha.Write(binary.BigEndian.AppendUint64(nil, counter))
+	return sha.Sum(nil)
+}
+
+type hkdfSHA256Amplifier struct{}
+
+func (hkdfSHA256Amplifier) ID() string {
+	return "hkdf-sha256"
+}
+
+func (hkdfSHA256Amplifier) Block(β []byte, counter uint64) []byte {
+	info := binary.BigEndian.AppendUint64([]byte("randomness/hkdf-sha256"), counter)
+	block, err := hkdf.Key(sha256.New, β, nil, string(info), 64)
+	if err != nil {
+		// Only returned for key lengths beyond 255 hash blocks.
+		panic(err)
+	}
+	return block
+}
+
+type shake256Amplifier struct{}
+
+func (shake256Amplifier) ID() string {
+	return "shake256"
+}
+
+func (shake256Amplifier) Block(β []byte, counter uint64) []byte {
+	input := binary.BigEndian.AppendUint64(append([]byte(nil), β...), counter)
+	return sha3.SumSHAKE256(input, 64)
+}
+
+type chaCha20Amplifier struct{}
+
+func (chaCha20Amplifier) ID() string {
+	return "chacha20"
+}
+
+func (chaCha20Amplifier) Block(β []byte, counter uint64) []byte {
+	key := sha256.Sum256(β)
+	var nonce [12]byte
+	binary.BigEndian.PutUint32(nonce[:], uint32((counter-1)>>32))
+	return chaCha20Block(key, uint32(counter-1), nonce)
+}
+
+// chaCha20Block returns one 64 byte block of the RFC 8439 ChaCha20 keystream.
+func chaCha20Block(key [32]byte, counter uint32, nonce [12]byte) []byte {
+	var state [16]uint32
+	state[0], state[1], state[2], state[3] = 0x61707865, 0x3320646e, 0x79622d32, 0x6b206574
+	for i := range 8 {
+		state[4+i] = binary.LittleEndian.Uint32(key[4*i:])
+	}
+	state[12] = counter
+	for i := range 3 {
+		state[13+i] = binary.LittleEndian.Uint32(nonce[4*i:])
+	}
+
+	x := state
+	quarterRound := func(a, b, c, d int) {
+		x[a] += x[b]
+		x[d] = bits.RotateLeft32(x[d]^x[a], 16)
+		x[c] += x[d]
+		x[b] = bits.RotateLeft32(x[b]^x[c], 12)
+		x[a] += x[b]
+		x[d] = bits.RotateLeft32(x[d]^x[a], 8)
+		x[c] += x[d]
+		x[b] = bits.RotateLeft32(x[b]^x[c], 7)
+	}
+	for range 10 {
+		quarterRound(0, 4, 8, 12)
+		quarterRound(1, 5, 9, 13)
+		quarterRound(2, 6, 10, 14)
+		quarterRound(3, 7, 11, 15)
+		quarterRound(0, 5, 10, 15)
+		quarterRound(1, 6, 11, 12)
+		quarterRound(2, 7, 8, 13)
+		quarterRound(3, 4, 9, 14)
+	}
+
+	block := make([]byte, 64)
+	for i := range x {
+		binary.LittleEndian.PutUint32(block[4*i:], x[i]+state[i])
+	}
+	return block
+}
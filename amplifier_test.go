@@ -0,0 +1,134 @@
+package randomness
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestAmplifierBlocks(t *testing.T) {
+	tests := []struct {
+		amplifier Amplifier
+		expected  string // Block([]byte("test"), 1)
+	}{
+		{
+			amplifier: AmplifierSHA512,
+			expected:  "3c986704c87a71db3e6958926ab8a0b2bf2f31346b89fcda80eb993ccb89edba1b765fea0e0700804010175ad9d4d240e22369ee2979e5908f4607fc2e242d24",
+		},
+		{
+			amplifier: AmplifierHKDFSHA256,
+			expected:  "58bf82e9a88f9b890e45cbb32804f219ce75f8d16d59f4af38afb90fd569f8857df721986e5ee430ce521049e460bf26f81e1429a3d6a9f8122dc7b431b095c7",
+		},
+		{
+			amplifier: AmplifierSHAKE256,
+			expected:  "da0e8bc7064a81acaf5235cf3ce30631bc638d7b2bf68f3db92dbf098ec4ca995ad6d71d8600d845e6f96720e6811589813fbae8f41048cae2d924bbacd9c57f",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.amplifier.ID(), func(t *testing.T) {
+			got := hex.EncodeToString(tt.amplifier.Block([]byte("test"), 1))
+			if got != tt.expected {
+				t.Errorf("Block() = %s, want %s", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestChaCha20Block(t *testing.T) {
+	// RFC 8439, section 2.3.2.
+	var key [32]byte
+	for i := range key {
+		key[i] = byte(i)
+	}
+	nonce := [12]byte{0, 0, 0, 0x09, 0, 0, 0, 0x4a, 0, 0, 0, 0}
+	expected := "10f1e7e4d13b5915500fdd1fa32071c4c7d1f4c733c068030422aa9ac3d46c4ed2826446079faa0914c2d705d98b02a2b5129cd1de164eb9cbd083e8a2503c4e"
+	if got := hex.EncodeToString(chaCha20Block(key, 1, nonce)); got != expected {
+		t.Errorf("chaCha20Block() = %s, want %s", got, expected)
+	}
+}
+
+func TestWithAmplifier(t *testing.T) {
+	for _, a := range []Amplifier{AmplifierSHA512, AmplifierHKDFSHA256, AmplifierSHAKE256, AmplifierChaCha20} {
+		t.Run(a.ID(), func(t *testing.T) {
+			r := NewRandomness(BetaBytes("test"), WithAmplifier(a))
+			if r.Amplifier().ID() != a.ID() {
+				t.Errorf("Amplifier() = %s, want %s", r.Amplifier().ID(), a.ID())
+			}
+
+			got, err := r.Bytes(4 + 128)
+			if err != nil {
+				t.Fatalf("Bytes() error = %v", err)
+			}
+			expected := append([]byte("test"), a.Block([]byte("test"), 1)...)
+			expected = append(expected, a.Block([]byte("test"), 2)...)
+			if !bytes.Equal(got, expected) {
+				t.Errorf("Bytes() = %x, want %x", got, expected)
+			}
+
+			found, err := AmplifierByID(a.ID())
+			if err != nil || found != a {
+				t.Errorf("AmplifierByID(%q) = %v, %v", a.ID(), found, err)
+			}
+		})
+	}
+
+	if NewRandomness(BetaBytes("test")).Amplifier() != AmplifierSHA512 {
+		t.Error("NewRandomness() does not default to AmplifierSHA512")
+	}
+	if _, err := AmplifierByID("md5"); err == nil {
+		t.Error("AmplifierByID() found an unknown amplifier")
+	}
+	if err := RegisterAmplifier(AmplifierSHA512); err == nil {
+		t.Error("RegisterAmplifier() accepted a duplicate identifier")
+	}
+}
+
+// emptyAmplifier returns no bytes, so the stream can never grow.
+type emptyAmplifier struct{}
+
+func (emptyAmplifier) ID() string                  { return "empty" }
+func (emptyAmplifier) Block([]byte, uint64) []byte { return nil }
+
+func TestEmptyAmplifier(t *testing.T) {
+	r := NewRandomness(BetaBytes("test"), WithAmplifier(emptyAmplifier{}))
+	if _, err := r.Bytes(4); err != nil {
+		t.Fatalf("Bytes() of the beta error = %v", err)
+	}
+	if _, err := r.Uint64(); err == nil {
+		t.Error("Uint64() past the beta did not return an error")
+	}
+
+	bits := NewRandomness(BetaBytes("test"), WithAmplifier(emptyAmplifier{}), WithStream(StreamBits))
+	if _, err := bits.Bits(40); err == nil {
+		t.Error("Bits() past the beta did not return an error")
+	}
+}
+
+func TestRegisterAmplifierConcurrently(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			RegisterAmplifier(namedAmplifier(fmt.Sprintf("concurrent-%d", i)))
+			AmplifierByID(AmplifierSHA512.ID())
+		}()
+	}
+	wg.Wait()
+	for i := range 8 {
+		if _, err := AmplifierByID(fmt.Sprintf("concurrent-%d", i)); err != nil {
+			t.Errorf("AmplifierByID() error = %v", err)
+		}
+	}
+}
+
+// namedAmplifier is AmplifierSHA512 registered under another identifier.
+type namedAmplifier string
+
+func (a namedAmplifier) ID() string { return string(a) }
+func (a namedAmplifier) Block(β []byte, counter uint64) []byte {
+	return AmplifierSHA512.Block(β, counter)
+}
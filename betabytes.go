@@ -22,6 +22,21 @@ func (b BetaBytes) Bytes() []byte {
 	return b
 }
 
+// MarshalText hex encodes the bytes.
+func (b BetaBytes) MarshalText() ([]byte, error) {
+	return []byte(b.String()), nil
+}
+
+// UnmarshalText decodes hex encoded bytes.
+func (b *BetaBytes) UnmarshalText(text []byte) error {
+	bytes, err := BetaBytesFromHex(string(text))
+	if err != nil {
+		return err
+	}
+	*b = bytes
+	return nil
+}
+
 func BetaBytesFromHex(β string) (BetaBytes, error) {
 	bytes, err := hex.DecodeString(β)
 	if err != nil {
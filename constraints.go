@@ -0,0 +1,247 @@
+package randomness
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Tagger is implemented by items that belong to categories, which selection
+// constraints can limit.
+type Tagger interface {
+	Tags() []string
+}
+
+// Identifier is implemented by items with a stable identity. With
+// SelectionConfig.UniqueIDs at most one instance of any ID is selected.
+type Identifier interface {
+	ID() string
+}
+
+// NewTaggedItem creates a GenericItem with an ID and tags.
+func NewTaggedItem[T any](value T, weight float64, supply int, id string, tags ...string) *GenericItem[T] {
+	item := NewGenericItem(value, weight, supply)
+	item.SetID(id)
+	item.SetTags(tags...)
+	return item
+}
+
+var (
+	_ Tagger     = &GenericItem[any]{}
+	_ Identifier = &GenericItem[any]{}
+)
+
+// TagConstraint bounds the number of selected instances of items with a tag
+// within a single Selection.
+type TagConstraint struct {
+	Tag string
+	Min int
+	Max int // Zero means no maximum
+}
+
+// constraints tracks the constraints of a selection as instances are taken.
+type constraints struct {
+	states []itemState
+	limits []TagConstraint
+	unique bool
+	tags   [][]int  // Indices of the constraints matching each item
+	ids    []string // ID of each item, empty for none
+	counts []int    // Selected instances for each constraint
+	used   map[string]bool
+	memo   map[string]bool
+	budget int
+}
+
+// maxConstraintNodes bounds the search for a completion of a constrained
+// selection.
+const maxConstraintNodes = 1 << 20
+
+// constrained reports whether the configuration has constraints.
+func (c *SelectionConfig) constrained() bool {
+	return len(c.Constraints) > 0 || c.UniqueIDs
+}
+
+func newConstraints(cfg SelectionConfig, states []itemState) (*constraints, error) {
+	for _, limit := range cfg.Constraints {
+		switch {
+		case limit.Tag == "":
+			return nil, fmt.Errorf("constraint tag must not be empty")
+		case limit.Min < 0 || limit.Max < 0:
+			return nil, fmt.Errorf("constraint on %q must have non-negative bounds", limit.Tag)
+		case limit.Max > 0 && limit.Min > limit.Max:
+			return nil, fmt.Errorf("constraint on %q has minimum %d above maximum %d", limit.Tag, limit.Min, limit.Max)
+		}
+	}
+	c := &constraints{
+		states: states,
+		limits: cfg.Constraints,
+		unique: cfg.UniqueIDs,
+		tags:   make([][]int, len(states)),
+		ids:    make([]string, len(states)),
+		counts: make([]int, len(cfg.Constraints)),
+		used:   make(map[string]bool),
+	}
+	for i, state := range states {
+		if t, ok := state.Item.(Tagger); ok {
+			tags := t.Tags()
+			for j, limit := range cfg.Constraints {
+				if slices.Contains(tags, limit.Tag) {
+					c.tags[i] = append(c.tags[i], j)
+				}
+			}
+		}
+		if id, ok := state.Item.(Identifier); ok && c.unique {
+			c.ids[i] = id.ID()
+		}
+	}
+	return c, nil
+}
+
+// limit returns how many more instances of item i can be taken without
+// breaking a maximum, a unique ID or its supply, capped at n.
+func (c *constraints) limit(i, n int) int {
+	state := &c.states[i]
+	if state.Item.Weight() <= 0 {
+		return 0
+	}
+	k := n
+	if state.IsConsumed {
+		k = min(k, state.RemainingSupply)
+	}
+	if id := c.ids[i]; id != "" {
+		if c.used[id] {
+			return 0
+		}
+		k = min(k, 1)
+	}
+	for _, j := range c.tags[i] {
+		if m := c.limits[j].Max; m > 0 {
+			k = min(k, m-c.counts[j])
+		}
+	}
+	return max(k, 0)
+}
+
+// take records k selected instances of item i, or removes them if k is
+// negative.
+func (c *constraints) take(i, k int) {
+	for _, j := range c.tags[i] {
+		c.counts[j] += k
+	}
+	if id := c.ids[i]; id != "" && k != 0 {
+		c.used[id] = k > 0
+	}
+}
+
+// feasible reports whether n more instances can be selected while meeting
+// every constraint. It searches for a completion item by item, deciding how
+// many instances of each to take, with memoization and a bound on the number
+// of steps.
+func (c *constraints) feasible(n int) (bool, error) {
+	c.memo = make(map[string]bool)
+	c.budget = maxConstraintNodes
+	return c.search(0, n)
+}
+
+func (c *constraints) search(i, n int) (bool, error) {
+	met := true
+	for j, limit := range c.limits {
+		if c.counts[j] < limit.Min {
+			met = false
+		}
+	}
+	if n == 0 || i == len(c.states) {
+		return n == 0 && met, nil
+	}
+
+	c.budget--
+	if c.budget < 0 {
+		return false, fmt.Errorf("selection constraints are too complex to check")
+	}
+	key := c.key(i, n)
+	if ok, seen := c.memo[key]; seen {
+		return ok, nil
+	}
+
+	ok := false
+	for k := c.limit(i, n); k >= 0 && !ok; k-- {
+		c.take(i, k)
+		var err error
+		ok, err = c.search(i+1, n-k)
+		c.take(i, -k)
+		if err != nil {
+			return false, err
+		}
+	}
+	c.memo[key] = ok
+	return ok, nil
+}
+
+func (c *constraints) key(i, n int) string {
+	var b strings.Builder
+	b.WriteString(strconv.Itoa(i))
+	b.WriteByte('/')
+	b.WriteString(strconv.Itoa(n))
+	for _, count := range c.counts {
+		b.WriteByte(',')
+		b.WriteString(strconv.Itoa(count))
+	}
+	if c.unique {
+		// Only the IDs of later items affect the rest of the search.
+		for _, id := range c.ids[i:] {
+			if id != "" && c.used[id] {
+				b.WriteByte('|')
+				b.WriteString(id)
+			}
+		}
+	}
+	return b.String()
+}
+
+// selectConstrained makes count selections from the item states with the
+// engine, one at a time. Before each selection the items are filtered to
+// those of which one more instance can be taken with the rest of the
+// selection still able to meet every constraint, and the selection is made
+// from those items alone, in their original order with their own weights.
+func selectConstrained(r Randomness, c *constraints, count int, engine func(Randomness, []itemState, int) ([]SelectionResult, error)) ([]SelectionResult, error) {
+	results := make([]SelectionResult, 0, count)
+	for draw := range count {
+		var eligible []int
+		for i := range c.states {
+			if c.limit(i, 1) == 0 {
+				continue
+			}
+			c.take(i, 1)
+			ok, err := c.feasible(count - draw - 1)
+			c.take(i, -1)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				eligible = append(eligible, i)
+			}
+		}
+		if len(eligible) == 0 {
+			return nil, fmt.Errorf("selection constraints cannot be satisfied")
+		}
+
+		sub := make([]itemState, len(eligible))
+		for j, i := range eligible {
+			sub[j] = c.states[i]
+		}
+		selected, err := engine(r, sub, 1)
+		if err != nil {
+			return nil, err
+		}
+		for j, i := range eligible {
+			c.states[i] = sub[j]
+		}
+
+		result := selected[0].(*selectionResult)
+		result.index = eligible[result.index]
+		c.take(result.index, 1)
+		results = append(results, result)
+	}
+	return results, nil
+}
@@ -0,0 +1,163 @@
+package randomness
+
+import (
+	"slices"
+	"testing"
+)
+
+func rewardItems() []Item {
+	return []Item{
+		NewTaggedItem("sword", 1, 1, "sword", "legendary", "weapon"),
+		NewTaggedItem("crown", 1, 1, "crown", "legendary"),
+		NewTaggedItem("potion", 2, 3, "potion", "consumable"),
+		NewTaggedItem("scroll", 2, -1, "scroll", "consumable"),
+		NewTaggedItem("dagger", 5, 2, "dagger", "weapon"),
+		NewTaggedItem("coin", 10, -1, "coin"),
+		NewTaggedItem("gem", 4, 2, "gem"),
+	}
+}
+
+func TestConstrainedSelection(t *testing.T) {
+	cfg := SelectionConfig{
+		Items: rewardItems(),
+		Count: 5,
+		Constraints: []TagConstraint{
+			{Tag: "legendary", Max: 1},
+			{Tag: "consumable", Min: 2},
+		},
+		UniqueIDs: true,
+	}
+	if err := ValidateSelectionConfig(cfg); err != nil {
+		t.Fatalf("ValidateSelectionConfig() error = %v", err)
+	}
+
+	r, _ := NewRandomnessV(AlgorithmLatest, BetaBytes("constraints"))
+	legendaries := 0
+	for range 2000 {
+		results, err := r.Selection(cfg)
+		if err != nil {
+			t.Fatalf("Selection() error = %v", err)
+		}
+		if len(results) != 5 {
+			t.Fatalf("Selection() returned %d results, want 5", len(results))
+		}
+		ids := make(map[string]bool)
+		counts := make(map[string]int)
+		for _, result := range results {
+			id := result.Get().(Identifier).ID()
+			if ids[id] {
+				t.Fatalf("Selection() selected %s twice", id)
+			}
+			ids[id] = true
+			for _, tag := range result.Get().(Tagger).Tags() {
+				counts[tag]++
+			}
+		}
+		if counts["legendary"] > 1 || counts["consumable"] < 2 {
+			t.Fatalf("Selection() broke the constraints: %v", counts)
+		}
+		legendaries += counts["legendary"]
+	}
+	if legendaries == 0 {
+		t.Error("Selection() never selected a legendary item")
+	}
+}
+
+func TestConstrainedSelectionConsumption(t *testing.T) {
+	// Constraints that never bind give the same selections as none.
+	cfg := SelectionConfig{Items: rewardItems(), Count: 4}
+	plain, err := NewRandomness(BetaBytes("test")).Selection(cfg)
+	if err != nil {
+		t.Fatalf("Selection() error = %v", err)
+	}
+	cfg.Constraints = []TagConstraint{{Tag: "weapon", Max: 10}}
+	constrained, err := NewRandomness(BetaBytes("test")).Selection(cfg)
+	if err != nil {
+		t.Fatalf("Selection() error = %v", err)
+	}
+	for i := range plain {
+		if plain[i].Get() != constrained[i].Get() || plain[i].Instance() != constrained[i].Instance() {
+			t.Errorf("Selection()[%d] = %v, want %v", i, constrained[i].Any(), plain[i].Any())
+		}
+	}
+
+	// Once the remaining draws are needed for a minimum, only items with
+	// the tag are eligible.
+	cfg = SelectionConfig{
+		Items:       rewardItems(),
+		Count:       3,
+		Constraints: []TagConstraint{{Tag: "legendary", Min: 2}},
+		UniqueIDs:   true,
+	}
+	r := NewRandomness(BetaBytes("test"))
+	for range 100 {
+		results, err := r.Selection(cfg)
+		if err != nil {
+			t.Fatalf("Selection() error = %v", err)
+		}
+		var values []string
+		for _, result := range results {
+			values = append(values, result.Any().(string))
+		}
+		if !slices.Contains(values, "sword") || !slices.Contains(values, "crown") {
+			t.Fatalf("Selection() = %v, want both legendary items", values)
+		}
+	}
+}
+
+func TestUnsatisfiableConstraints(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  SelectionConfig
+	}{
+		{"minimum above supply", SelectionConfig{
+			Items:       rewardItems(),
+			Count:       3,
+			Constraints: []TagConstraint{{Tag: "legendary", Min: 3}},
+		}},
+		{"minimums above count", SelectionConfig{
+			Items:       rewardItems(),
+			Count:       2,
+			Constraints: []TagConstraint{{Tag: "legendary", Min: 1}, {Tag: "consumable", Min: 2}},
+		}},
+		{"unique IDs", SelectionConfig{
+			Items:       rewardItems(),
+			Count:       3,
+			Constraints: []TagConstraint{{Tag: "consumable", Min: 3}},
+			UniqueIDs:   true,
+		}},
+		{"minimum above maximum", SelectionConfig{
+			Items:       rewardItems(),
+			Count:       3,
+			Constraints: []TagConstraint{{Tag: "weapon", Min: 2, Max: 1}},
+		}},
+		{"empty tag", SelectionConfig{
+			Items:       rewardItems(),
+			Count:       3,
+			Constraints: []TagConstraint{{Max: 1}},
+		}},
+	}
+	for _, tt := range tests {
+		if err := ValidateSelectionConfig(tt.cfg); err == nil {
+			t.Errorf("ValidateSelectionConfig() with %s did not return an error", tt.name)
+		}
+		if _, err := NewRandomness(BetaBytes("test")).Selection(tt.cfg); err == nil {
+			t.Errorf("Selection() with %s did not return an error", tt.name)
+		}
+	}
+
+	// A single legendary is enough if the other items are all consumed.
+	items := []Item{
+		NewTaggedItem("sword", 1, 1, "sword", "legendary"),
+		NewTaggedItem("coin", 1, 2, "coin"),
+	}
+	cfg := SelectionConfig{Items: items, Count: 3, Constraints: []TagConstraint{{Tag: "legendary", Max: 1}}}
+	if err := ValidateSelectionConfig(cfg); err != nil {
+		t.Errorf("ValidateSelectionConfig() error = %v", err)
+	}
+	cfg.Constraints[0].Max = 0
+	cfg.Constraints[0].Min = 2
+	if err := ValidateSelectionConfig(cfg); err == nil {
+		t.Error("ValidateSelectionConfig() accepted two of a single legendary")
+	}
+}
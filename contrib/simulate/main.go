@@ -0,0 +1,108 @@
+// Command simulate plays a pay table many times and reports its return to
+// player. The pay table is a JSON array of items:
+//
+//	[
+//	  {"payout": 0, "weight": 90},
+//	  {"payout": 5, "weight": 9},
+//	  {"payout": 50, "weight": 1}
+//	]
+//
+// Every round selects -draws items from the table and pays the sum of their
+// payouts. Items have an infinite supply unless a non-negative "supply" is
+// given, in which case each round draws without replacement.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+
+	"github.com/revision-3/randomness"
+	"github.com/revision-3/randomness/simulate"
+)
+
+var (
+	table      = flag.String("table", "", "JSON pay table to simulate")
+	rounds     = flag.Int("rounds", 1000000, "Number of rounds to play")
+	draws      = flag.Int("draws", 1, "Number of items selected per round")
+	workers    = flag.Int("workers", 0, "Number of goroutines, defaults to GOMAXPROCS")
+	seed       = flag.String("seed", "", "Hex seed of the simulation, random by default")
+	algorithm  = flag.String("algorithm", randomness.AlgorithmLatest.String(), "Algorithm version")
+	confidence = flag.Float64("confidence", 0.95, "Confidence level of the RTP interval")
+	exact      = flag.Bool("exact", false, "Select with exact integer weights")
+)
+
+type entry struct {
+	Payout float64 `json:"payout"`
+	Weight float64 `json:"weight"`
+	Supply *int    `json:"supply"`
+}
+
+func main() {
+	flag.Parse()
+	if *table == "" {
+		log.Fatal("-table is required")
+	}
+
+	items, err := loadTable(*table)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	cfg := simulate.Config{
+		Rounds:     *rounds,
+		Workers:    *workers,
+		Confidence: *confidence,
+	}
+	if cfg.Algorithm, err = randomness.ParseAlgorithm(*algorithm); err != nil {
+		log.Fatal(err)
+	}
+	if *seed != "" {
+		if cfg.Seed, err = randomness.BetaBytesFromHex(*seed); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	report, err := simulate.Run(ctx, cfg, func(r randomness.Randomness) (float64, error) {
+		results, err := r.Selection(randomness.SelectionConfig{Items: items, Count: *draws, Exact: *exact})
+		if err != nil {
+			return 0, err
+		}
+		payout := 0.0
+		for _, result := range results {
+			payout += result.Any().(float64)
+		}
+		return payout, nil
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Print(report)
+}
+
+func loadTable(path string) ([]randomness.Item, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var entries []entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("cannot parse pay table: %w", err)
+	}
+	items := make([]randomness.Item, len(entries))
+	for i, e := range entries {
+		supply := -1
+		if e.Supply != nil {
+			supply = *e.Supply
+		}
+		items[i] = randomness.NewGenericItem(e.Payout, e.Weight, supply)
+	}
+	return items, nil
+}
@@ -0,0 +1,71 @@
+package randomness
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// PickWeightedDistinct picks k distinct items by weight and returns their
+// indices in items, in the order they were picked. Each item is picked at
+// most once whatever its supply, except that items with a supply of zero are
+// never picked.
+//
+// Items are picked by sequential renormalization: the first pick takes item
+// i with probability w_i / W, where W is the total weight, and each later
+// pick does the same over the items not yet picked. This is the same as a
+// Selection of k from the items with a supply of one each, so the exact
+// probability that each item is included is CalculateOdds(...).Expected(i)
+// for that configuration. Inclusion is not proportional to weight when k > 1:
+// heavy items saturate towards certainty and light items gain relative to
+// their weight.
+//
+// The weights are exact (see ExactWeighter) and scaled to integers, and each
+// pick is a single uniform integer over the remaining total drawn with the
+// rejection method of SamplingRejection, whatever the sampling of r.
+func PickWeightedDistinct(r Randomness, items []Item, k int) (v []int, err error) {
+	if b, ok := r.(*randomness); ok {
+		defer b.record("PickWeightedDistinct", &v)()
+	}
+	return pickWeightedDistinct(r, items, k)
+}
+
+func pickWeightedDistinct(r Randomness, items []Item, k int) ([]int, error) {
+	if k < 0 {
+		return nil, fmt.Errorf("cannot pick %d items: count must be non-negative", k)
+	}
+	weights, err := integerWeights(items)
+	if err != nil {
+		return nil, err
+	}
+
+	total := new(big.Int)
+	available := 0
+	for i, item := range items {
+		if item.Supply() == 0 {
+			weights[i].SetInt64(0)
+		}
+		if weights[i].Sign() > 0 {
+			total.Add(total, weights[i])
+			available++
+		}
+	}
+	if available < k {
+		return nil, fmt.Errorf("cannot pick %d distinct items from only %d with non-zero weight and supply", k, available)
+	}
+
+	picked := make([]int, 0, k)
+	for range k {
+		u, err := uniformBig(r, total)
+		if err != nil {
+			return nil, err
+		}
+		i := 0
+		for ; u.Cmp(weights[i]) >= 0; i++ {
+			u.Sub(u, weights[i])
+		}
+		picked = append(picked, i)
+		total.Sub(total, weights[i])
+		weights[i] = new(big.Int)
+	}
+	return picked, nil
+}
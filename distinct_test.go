@@ -0,0 +1,68 @@
+package randomness
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestPickWeightedDistinct(t *testing.T) {
+	items := []Item{
+		NewGenericItem("a", 1, -1),
+		NewGenericItem("b", 2, 5),
+		NewGenericItem("c", 3, 1),
+		NewGenericItem("d", 4, 0),
+	}
+	picked, err := PickWeightedDistinct(NewRandomness(BetaBytes("test")), items, 3)
+	if err != nil {
+		t.Fatalf("PickWeightedDistinct() error = %v", err)
+	}
+	// The total weight is 6, so the first pick reads one byte masked to
+	// three bits: 't' & 7 = 4 picks c. The remaining total is 3, so 'e' & 3
+	// = 1 picks b, and the lone item left is picked without reading.
+	if want := []int{2, 1, 0}; !slices.Equal(picked, want) {
+		t.Errorf("PickWeightedDistinct() = %v, want %v", picked, want)
+	}
+
+	if _, err := PickWeightedDistinct(NewRandomness(BetaBytes("test")), items, 4); err == nil {
+		t.Error("PickWeightedDistinct() picked an item with zero supply")
+	}
+	if _, err := PickWeightedDistinct(NewRandomness(BetaBytes("test")), items, -1); err == nil {
+		t.Error("PickWeightedDistinct() accepted a negative count")
+	}
+}
+
+func TestPickWeightedDistinctInclusion(t *testing.T) {
+	weights := []float64{1, 2, 3, 10}
+	var items, single []Item
+	for i, w := range weights {
+		items = append(items, NewGenericItem(i, w, -1))
+		single = append(single, NewGenericItem(i, w, 1))
+	}
+	odds, err := CalculateOdds(SelectionConfig{Items: single, Count: 2})
+	if err != nil {
+		t.Fatalf("CalculateOdds() error = %v", err)
+	}
+
+	const rounds = 40000
+	r := NewRandomness(BetaBytes("distinct"))
+	counts := make([]int, len(items))
+	for range rounds {
+		picked, err := PickWeightedDistinct(r, items, 2)
+		if err != nil {
+			t.Fatalf("PickWeightedDistinct() error = %v", err)
+		}
+		if picked[0] == picked[1] {
+			t.Fatalf("PickWeightedDistinct() = %v, want distinct items", picked)
+		}
+		for _, i := range picked {
+			counts[i]++
+		}
+	}
+	for i, count := range counts {
+		want, _ := odds.Expected(i).Float64()
+		if got := float64(count) / rounds; math.Abs(got-want) > 0.01 {
+			t.Errorf("inclusion of item %d = %f, want %f", i, got, want)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package randomness
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+)
+
+// ExactWeighter is implemented by items with an exact rational weight. In
+// exact selections it takes the place of Item.Weight.
+type ExactWeighter interface {
+	ExactWeight() *big.Rat
+}
+
+// ExactItem is a GenericItem with an exact rational weight. Weight returns
+// the nearest float64.
+type ExactItem[T any] struct {
+	GenericItem[T]
+	exact *big.Rat
+}
+
+// NewExactItem creates an item with an exact rational weight, e.g.
+// big.NewRat(1, 1_000_000_000) for a one in a billion jackpot.
+func NewExactItem[T any](value T, weight *big.Rat, supply int) *ExactItem[T] {
+	f, _ := weight.Float64()
+	return &ExactItem[T]{
+		GenericItem: *NewGenericItem(value, f, supply),
+		exact:       new(big.Rat).Set(weight),
+	}
+}
+
+func (i *ExactItem[T]) ExactWeight() *big.Rat {
+	return new(big.Rat).Set(i.exact)
+}
+
+var _ ExactWeighter = &ExactItem[any]{}
+
+// exactWeight returns the exact weight of an item: its ExactWeight if it
+// implements ExactWeighter, otherwise the exact value of its float64 weight.
+func exactWeight(item Item) (*big.Rat, error) {
+	if e, ok := item.(ExactWeighter); ok {
+		w := e.ExactWeight()
+		if w == nil || w.Sign() < 0 {
+			return nil, fmt.Errorf("weights must be non-negative")
+		}
+		return w, nil
+	}
+	w := item.Weight()
+	if math.IsNaN(w) || math.IsInf(w, 0) {
+		return nil, fmt.Errorf("weights must be finite")
+	}
+	if w < 0 {
+		return nil, fmt.Errorf("weights must be non-negative")
+	}
+	return new(big.Rat).SetFloat64(w), nil
+}
+
+// integerWeights returns the exact weights of the items scaled by the least
+// common multiple of their denominators, so that every weight is an integer
+// in the same ratio.
+func integerWeights(items []Item) ([]*big.Int, error) {
+	rats := make([]*big.Rat, len(items))
+	lcm := big.NewInt(1)
+	for i, item := range items {
+		w, err := exactWeight(item)
+		if err != nil {
+			return nil, err
+		}
+		rats[i] = w
+		gcd := new(big.Int).GCD(nil, nil, lcm, w.Denom())
+		lcm.Mul(lcm, new(big.Int).Quo(w.Denom(), gcd))
+	}
+
+	weights := make([]*big.Int, len(items))
+	for i, w := range rats {
+		weights[i] = new(big.Int).Mul(w.Num(), new(big.Int).Quo(lcm, w.Denom()))
+	}
+	return weights, nil
+}
+
+// selectInstancesExact makes count weighted selections from the item states
+// with exact integer arithmetic.
+//
+// The exact weights are scaled to integers (see integerWeights) and each
+// available instance, or each unit of supply magnitude of an infinite item,
+// covers an interval of its integer weight. A uniform integer is drawn over
+// the total with uniformBig and the items are walked in order to find the
+// interval it falls in. Within a finite item the quotient by the weight
+// selects the n-th unused instance and the remainder gives the fraction.
+func selectInstancesExact(r Randomness, states []itemState, count int) ([]SelectionResult, error) {
+	items := make([]Item, len(states))
+	for i := range states {
+		items[i] = states[i].Item
+	}
+	weights, err := integerWeights(items)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]SelectionResult, 0, count)
+	for range count {
+		spans := make([]*big.Int, len(states))
+		total := new(big.Int)
+		for i := range states {
+			spans[i] = new(big.Int).Mul(weights[i], big.NewInt(int64(states[i].units())))
+			total.Add(total, spans[i])
+		}
+		if total.Sign() == 0 {
+			return nil, fmt.Errorf("no items remaining with non-zero supply")
+		}
+
+		u, err := uniformBig(r, total)
+		if err != nil {
+			return nil, err
+		}
+
+		roll := new(big.Int).Set(u)
+		i := 0
+		for ; u.Cmp(spans[i]) >= 0; i++ {
+			u.Sub(u, spans[i])
+		}
+		state := &states[i]
+		before := new(big.Int).Sub(roll, u)
+		explanation := Explanation{
+			ItemWeight:  state.available(),
+			TotalWeight: availableWeight(states),
+		}
+		explanation.Probability, _ = new(big.Rat).SetFrac(spans[i], total).Float64()
+		explanation.Roll, _ = new(big.Rat).SetFrac(roll, total).Float64()
+		explanation.Low, _ = new(big.Rat).SetFrac(before, total).Float64()
+		explanation.High, _ = new(big.Rat).SetFrac(before.Add(before, spans[i]), total).Float64()
+
+		instance := 1
+		var fraction float64
+		if state.IsConsumed {
+			rank, offset := new(big.Int).QuoRem(u, weights[i], new(big.Int))
+			fraction, _ = new(big.Rat).SetFrac(offset, weights[i]).Float64()
+			instance = state.unusedInstance(int(rank.Int64()))
+			state.use(instance)
+		} else {
+			// Every unit of an infinite supply is instance 1.
+			fraction, _ = new(big.Rat).SetFrac(u, spans[i]).Float64()
+		}
+
+		results = append(results, &selectionResult{
+			Item:        state.Item,
+			instance:    instance,
+			fraction:    fraction,
+			index:       i,
+			explanation: explanation,
+		})
+	}
+
+	return results, nil
+}
+
+// availableWeight returns the total float64 weight of the instances that
+// can still be selected.
+func availableWeight(states []itemState) float64 {
+	total := 0.0
+	for i := range states {
+		total += states[i].available()
+	}
+	return total
+}
+
+// units returns the number of weight units the item contributes: its
+// remaining supply if finite, or the magnitude of its supply if infinite.
+func (s *itemState) units() int {
+	if !s.IsConsumed {
+		return s.OriginalSupply
+	}
+	return s.RemainingSupply
+}
@@ -0,0 +1,125 @@
+package randomness
+
+import (
+	"math"
+	"math/big"
+	"testing"
+)
+
+func TestIntegerWeights(t *testing.T) {
+	items := []Item{
+		NewExactItem("a", big.NewRat(1, 3), 1),
+		NewGenericItem("b", 0.5, 1),
+		NewGenericItem("c", 2, 1),
+	}
+	weights, err := integerWeights(items)
+	if err != nil {
+		t.Fatalf("integerWeights() error = %v", err)
+	}
+	for i, want := range []int64{2, 3, 12} {
+		if weights[i].Int64() != want {
+			t.Errorf("weight %d = %s, want %d", i, weights[i], want)
+		}
+	}
+
+	if _, err := integerWeights([]Item{NewGenericItem("nan", math.NaN(), 1)}); err == nil {
+		t.Error("integerWeights() accepted a NaN weight")
+	}
+	if _, err := integerWeights([]Item{NewExactItem("neg", big.NewRat(-1, 2), 1)}); err == nil {
+		t.Error("integerWeights() accepted a negative weight")
+	}
+}
+
+func TestExactSelection(t *testing.T) {
+	items := []Item{
+		NewExactItem("a", big.NewRat(1, 3), -1),
+		NewExactItem("b", big.NewRat(2, 3), -1),
+	}
+
+	// The total weight is 3, so one byte masked to two bits is read and 3
+	// is rejected.
+	tests := []struct {
+		beta     BetaBytes
+		expected string
+	}{
+		{BetaValues(uint8(0)), "a"},
+		{BetaValues(uint8(1)), "b"},
+		{BetaValues(uint8(0xfe)), "b"},
+		{BetaValues(uint8(3), uint8(0)), "a"},
+	}
+	for _, tt := range tests {
+		r := NewRandomness(tt.beta)
+		results, err := r.Selection(SelectionConfig{Items: items, Count: 1, Exact: true})
+		if err != nil {
+			t.Fatalf("Selection() error = %v", err)
+		}
+		if got := results[0].Any(); got != tt.expected {
+			t.Errorf("Selection() with beta %s = %v, want %s", tt.beta, got, tt.expected)
+		}
+	}
+}
+
+func TestExactSelectionInstances(t *testing.T) {
+	items := []Item{
+		NewGenericItem("a", 1, 3),
+		NewGenericItem("b", 1, 1),
+	}
+	// u = 2 selects the third instance of a, then u = 2 again selects b.
+	r := NewRandomness(BetaValues(uint8(2), uint8(2)))
+	results, err := r.Selection(SelectionConfig{Items: items, Count: 2, Exact: true})
+	if err != nil {
+		t.Fatalf("Selection() error = %v", err)
+	}
+	if results[0].Any() != "a" || results[0].Instance() != 3 {
+		t.Errorf("first selection = %v/%d, want a/3", results[0].Any(), results[0].Instance())
+	}
+	if results[1].Any() != "b" || results[1].Instance() != 1 {
+		t.Errorf("second selection = %v/%d, want b/1", results[1].Any(), results[1].Instance())
+	}
+}
+
+func TestExactSelectionJackpot(t *testing.T) {
+	items := []Item{
+		NewExactItem("jackpot", big.NewRat(1, 1_000_000_000), -1),
+		NewGenericItem("nothing", 1, -1),
+	}
+	// The total weight is 1_000_000_001, so the jackpot is exactly u = 0.
+	r := NewRandomness(BetaValues(uint32(0)))
+	results, err := r.Selection(SelectionConfig{Items: items, Count: 1, Exact: true})
+	if err != nil {
+		t.Fatalf("Selection() error = %v", err)
+	}
+	if results[0].Any() != "jackpot" {
+		t.Errorf("Selection() = %v, want jackpot", results[0].Any())
+	}
+
+	r = NewRandomness(BetaValues(uint32(1)))
+	results, _ = r.Selection(SelectionConfig{Items: items, Count: 1, Exact: true})
+	if results[0].Any() != "nothing" {
+		t.Errorf("Selection() = %v, want nothing", results[0].Any())
+	}
+}
+
+func TestExactSelectionDistribution(t *testing.T) {
+	items := []Item{
+		&testItem{value: 1, weight: 1.0, supply: 3},
+		&testItem{value: 2, weight: 1.0, supply: 1},
+	}
+	iterations := 100000
+	counts := make(map[int]int)
+	r := NewRandomness(BetaValues(GenerateTestRandomValue()))
+	for range iterations {
+		results, err := r.Selection(SelectionConfig{Items: items, Count: 1, Exact: true})
+		if err != nil {
+			t.Fatalf("Selection() error = %v", err)
+		}
+		counts[results[0].Get().(*testItem).value]++
+	}
+
+	for value, p := range map[int]float64{1: 0.75, 2: 0.25} {
+		expected := float64(iterations) * p
+		if math.Abs(float64(counts[value])-expected)/expected > 0.05 {
+			t.Errorf("Value %d: count = %d, expected ≈ %.0f", value, counts[value], expected)
+		}
+	}
+}
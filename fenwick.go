@@ -0,0 +1,60 @@
+package randomness
+
+// fenwick is a Fenwick (binary indexed) tree over a fixed number of values,
+// supporting point updates, prefix sums and searching by prefix sum in
+// O(log n).
+type fenwick[T int | float64] struct {
+	tree []T // 1-indexed; tree[0] is unused
+}
+
+// newFenwick builds a tree over the given values in O(n).
+func newFenwick[T int | float64](values []T) *fenwick[T] {
+	tree := make([]T, len(values)+1)
+	copy(tree[1:], values)
+	for i := 1; i < len(tree); i++ {
+		if parent := i + i&-i; parent < len(tree) {
+			tree[parent] += tree[i]
+		}
+	}
+	return &fenwick[T]{tree: tree}
+}
+
+// len returns the number of values in the tree.
+func (f *fenwick[T]) len() int {
+	return len(f.tree) - 1
+}
+
+// add adds delta to value i.
+func (f *fenwick[T]) add(i int, delta T) {
+	for i++; i < len(f.tree); i += i & -i {
+		f.tree[i] += delta
+	}
+}
+
+// prefix returns the sum of values [0, i).
+func (f *fenwick[T]) prefix(i int) T {
+	var sum T
+	for ; i > 0; i -= i & -i {
+		sum += f.tree[i]
+	}
+	return sum
+}
+
+// search returns the smallest i such that the sum of values [0, i] is at
+// least target, along with the sum of values [0, i). If the total is less
+// than target it returns len().
+func (f *fenwick[T]) search(target T) (int, T) {
+	pos := 0
+	var before T
+	step := 1
+	for step*2 < len(f.tree) {
+		step *= 2
+	}
+	for ; step > 0; step /= 2 {
+		if next := pos + step; next < len(f.tree) && before+f.tree[next] < target {
+			pos = next
+			before += f.tree[next]
+		}
+	}
+	return pos, before
+}
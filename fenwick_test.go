@@ -0,0 +1,38 @@
+package randomness
+
+import "testing"
+
+func TestFenwick(t *testing.T) {
+	values := []int{3, 0, 1, 4, 1, 5, 9, 2, 6}
+	f := newFenwick(values)
+
+	sum := 0
+	for i := range values {
+		if got := f.prefix(i); got != sum {
+			t.Errorf("prefix(%d) = %d, want %d", i, got, sum)
+		}
+		sum += values[i]
+	}
+
+	tests := []struct {
+		target, index, before int
+	}{
+		{1, 0, 0},
+		{3, 0, 0},
+		{4, 2, 3},
+		{5, 3, 4},
+		{31, 8, 25},
+		{32, 9, 31},
+	}
+	for _, tt := range tests {
+		index, before := f.search(tt.target)
+		if index != tt.index || before != tt.before {
+			t.Errorf("search(%d) = %d, %d, want %d, %d", tt.target, index, before, tt.index, tt.before)
+		}
+	}
+
+	f.add(2, -1)
+	if index, _ := f.search(4); index != 3 {
+		t.Errorf("search(4) after add(2, -1) = %d, want 3", index)
+	}
+}
@@ -0,0 +1,31 @@
+package randomness
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+)
+
+// ForkBeta derives the beta of the child stream labelled label from β:
+//
+//	HMAC-SHA512(key = β, message = "fork:" + label)
+//
+// The result is 64 bytes long.
+func ForkBeta(β BetaBytes, label string) BetaBytes {
+	mac := hmac.New(sha512.New, β)
+	mac.Write([]byte("fork:"))
+	mac.Write([]byte(label))
+	return BetaBytes(mac.Sum(nil))
+}
+
+// Fork returns an independent child stream derived from the original beta and
+// label. The child uses the same algorithm version, sampling, stream and
+// amplifier but has its own cursor and no transcript, so draws from one
+// subsystem never perturb another. Forking does not consume from the parent,
+// and forking the same label twice yields identical streams.
+func (b *randomness) Fork(label string) Randomness {
+	child := newRandomness(b.algorithm, ForkBeta(b.beta, label))
+	child.sampling = b.sampling
+	child.stream = b.stream
+	child.amplifier = b.amplifier
+	return child
+}
@@ -0,0 +1,51 @@
+package randomness
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestForkBeta(t *testing.T) {
+	expected := "4df835aa08bc68e0afbd56ac87c405567c4967d61bf3b735f982d0e7c068a32547d4679c8da016f9de6e5c92de276a67210d4a057631316dfae98506c434d115"
+	if got := ForkBeta(BetaBytes("test"), "deck").String(); got != expected {
+		t.Errorf("ForkBeta() = %s, want %s", got, expected)
+	}
+}
+
+func TestFork(t *testing.T) {
+	r, _ := NewRandomnessV(AlgorithmV2, BetaBytes("test"), WithAmplifier(AmplifierChaCha20))
+	deck := r.Fork("deck")
+	if deck.Algorithm() != AlgorithmV2 || deck.Amplifier() != AmplifierChaCha20 {
+		t.Errorf("Fork() = %s/%s, want v2/chacha20", deck.Algorithm(), deck.Amplifier().ID())
+	}
+	want, err := deck.PickDistinct(5, 52)
+	if err != nil {
+		t.Fatalf("PickDistinct() error = %v", err)
+	}
+
+	// Draws on the parent or a sibling never perturb the child.
+	other, _ := NewRandomnessV(AlgorithmV2, BetaBytes("test"), WithAmplifier(AmplifierChaCha20))
+	other.Bytes(1000)
+	other.Fork("bonus-wheel").Uint64()
+	got, err := other.Fork("deck").PickDistinct(5, 52)
+	if err != nil {
+		t.Fatalf("PickDistinct() error = %v", err)
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("PickDistinct() = %v, want %v", got, want)
+	}
+
+	// Forking does not consume from the parent.
+	parent, _ := r.Uint64()
+	control, _ := NewRandomnessV(AlgorithmV2, BetaBytes("test"), WithAmplifier(AmplifierChaCha20))
+	if expected, _ := control.Uint64(); parent != expected {
+		t.Errorf("Uint64() after Fork() = %d, want %d", parent, expected)
+	}
+
+	// Different labels give different streams.
+	a, _ := r.Fork("a").Uint64()
+	b, _ := r.Fork("b").Uint64()
+	if a == b {
+		t.Error("Fork() returned the same stream for different labels")
+	}
+}
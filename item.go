@@ -1,6 +1,9 @@
 package randomness
 
-import "encoding/json"
+import (
+	"maps"
+	"slices"
+)
 
 type Itemer interface {
 	Weight() float64
@@ -20,10 +23,30 @@ type Item interface {
 	Any() any
 }
 
-// BaseItem provides a base implementation of the Item interface
+// BaseItem provides a base implementation of the Item interface. It is
+// comparable, so items that embed it by value can be compared with == and
+// used as map keys.
 type BaseItem struct {
 	weight float64
 	supply int
+	id     string
+	extra  *itemExtra // Tags and metadata, behind a pointer to stay comparable
+}
+
+// itemExtra holds the tags and metadata of an item. Copies of an item share
+// it, so it is replaced rather than modified.
+type itemExtra struct {
+	tags     []string
+	metadata map[string]any
+}
+
+// clone returns a copy of e that can be modified, or an empty one if e is
+// nil.
+func (e *itemExtra) clone() *itemExtra {
+	if e == nil {
+		return &itemExtra{}
+	}
+	return &itemExtra{tags: e.tags, metadata: maps.Clone(e.metadata)}
 }
 
 // GenericItem is a generic implementation of the Item interface as a generic type
@@ -47,38 +70,6 @@ func (i *GenericItem[T]) Value() T {
 	return i.value
 }
 
-func (i *GenericItem[T]) MarshalJSON() ([]byte, error) {
-	return json.Marshal(map[string]any{
-		"value":  i.value,
-		"weight": i.weight,
-		"supply": i.supply,
-	})
-}
-
-func (i *GenericItem[T]) UnmarshalJSON(data []byte) error {
-	var m map[string]any
-	if err := json.Unmarshal(data, &m); err != nil {
-		return err
-	}
-
-	i.weight = 1.0
-	i.supply = 1
-
-	if value, ok := m["value"].(T); ok {
-		i.value = value
-	}
-
-	if weight, ok := m["weight"].(float64); ok {
-		i.weight = weight
-	}
-
-	if supply, ok := m["supply"].(int); ok {
-		i.supply = supply
-	}
-
-	return nil
-}
-
 var _ TypedItemer[any] = &GenericItem[any]{}
 
 // Weight returns the weight of the item
@@ -95,6 +86,51 @@ func (i BaseItem) Value() any {
 	return nil
 }
 
+// ID returns the stable identifier of the item, or an empty string if it has
+// none.
+func (i BaseItem) ID() string {
+	return i.id
+}
+
+// Tags returns the categories of the item.
+func (i BaseItem) Tags() []string {
+	if i.extra == nil {
+		return nil
+	}
+	return slices.Clone(i.extra.tags)
+}
+
+// Metadata returns the free-form metadata of the item.
+func (i BaseItem) Metadata() map[string]any {
+	if i.extra == nil {
+		return nil
+	}
+	return maps.Clone(i.extra.metadata)
+}
+
+// SetID sets the stable identifier of the item.
+func (i *BaseItem) SetID(id string) {
+	i.id = id
+}
+
+// SetTags replaces the categories of the item.
+func (i *BaseItem) SetTags(tags ...string) {
+	extra := i.extra.clone()
+	extra.tags = slices.Clone(tags)
+	i.extra = extra
+}
+
+// SetMetadata sets a metadata value of the item. Metadata is encoded as
+// JSON, so values should be JSON values.
+func (i *BaseItem) SetMetadata(key string, value any) {
+	extra := i.extra.clone()
+	if extra.metadata == nil {
+		extra.metadata = make(map[string]any)
+	}
+	extra.metadata[key] = value
+	i.extra = extra
+}
+
 func SingleItem() BaseItem {
 	return BaseItem{
 		weight: 1,
@@ -0,0 +1,339 @@
+package randomness
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"sync"
+)
+
+// itemJSON is the JSON encoding of an item:
+//
+//	{
+//	  "id": "sword",
+//	  "type": "weapon",
+//	  "value": {"damage": 12},
+//	  "weight": 0.5,
+//	  "exactWeight": "1/2",
+//	  "supply": 3,
+//	  "tags": ["legendary"],
+//	  "metadata": {"art": "sword.png"}
+//	}
+//
+// Only weight and supply are always present. type names the value type the
+// item was registered with (see RegisterItemType) and exactWeight is the
+// exact weight of an ExactWeighter as a fraction. A missing weight or supply
+// decodes as 1, and unknown fields are rejected.
+//
+// Items used to be encoded as an object of value, weight and supply only,
+// with the keys in sorted order. Those encodings still decode, but values
+// that embed items, such as the Selection entries of a Transcript, are no
+// longer byte-for-byte equal to their old encoding; Transcript.Diff compares
+// values with their keys sorted for this reason.
+type itemJSON struct {
+	ID          string          `json:"id,omitempty"`
+	Type        string          `json:"type,omitempty"`
+	Value       json.RawMessage `json:"value,omitempty"`
+	Weight      *float64        `json:"weight"`
+	ExactWeight string          `json:"exactWeight,omitempty"`
+	Supply      *int            `json:"supply"`
+	Tags        []string        `json:"tags,omitempty"`
+	Metadata    map[string]any  `json:"metadata,omitempty"`
+}
+
+// itemTypes maps the names of registered value types to their decoders, and
+// itemTypeNames maps the types back to their names. Both are guarded by
+// itemTypesMu.
+var (
+	itemTypesMu   sync.RWMutex
+	itemTypes     = make(map[string]func(itemJSON) (Item, error))
+	itemTypeNames = make(map[reflect.Type]string)
+)
+
+// RegisterItemType registers the value type T under a name, so that items
+// with values of type T are encoded with that name and UnmarshalItems
+// decodes them as *GenericItem[T], or *ExactItem[T] if they have an exact
+// weight. It is safe to call concurrently with encoding and decoding.
+func RegisterItemType[T any](name string) error {
+	if name == "" {
+		return fmt.Errorf("item type name must not be empty")
+	}
+	itemTypesMu.Lock()
+	defer itemTypesMu.Unlock()
+	if _, ok := itemTypes[name]; ok {
+		return fmt.Errorf("item type %q is already registered", name)
+	}
+	t := reflect.TypeFor[T]()
+	if other, ok := itemTypeNames[t]; ok {
+		return fmt.Errorf("item type %s is already registered as %q", t, other)
+	}
+	itemTypes[name] = decodeTypedItem[T]
+	itemTypeNames[t] = name
+	return nil
+}
+
+// itemTypeDecoder returns the decoder of the registered type name.
+func itemTypeDecoder(name string) (func(itemJSON) (Item, error), bool) {
+	itemTypesMu.RLock()
+	defer itemTypesMu.RUnlock()
+	decode, ok := itemTypes[name]
+	return decode, ok
+}
+
+// itemTypeName returns the registered name of the type t, or "" if it is
+// not registered.
+func itemTypeName(t reflect.Type) string {
+	itemTypesMu.RLock()
+	defer itemTypesMu.RUnlock()
+	return itemTypeNames[t]
+}
+
+func decodeTypedItem[T any](j itemJSON) (Item, error) {
+	var item GenericItem[T]
+	if err := item.decode(j); err != nil {
+		return nil, err
+	}
+	if j.ExactWeight != "" {
+		exact, err := parseExactWeight(j.ExactWeight)
+		if err != nil {
+			return nil, err
+		}
+		return &ExactItem[T]{GenericItem: item, exact: exact}, nil
+	}
+	return &item, nil
+}
+
+// MarshalItems encodes items as a JSON array. Items that implement
+// json.Marshaler encode themselves; any other Item is encoded from its
+// Weight, Supply and Any, and its ID, Tags and ExactWeight if it has them.
+// The keys of every item are written in the fixed order id, type, value,
+// weight, exactWeight, supply, tags and metadata.
+func MarshalItems(items []Item) ([]byte, error) {
+	raw := make([]json.RawMessage, len(items))
+	for i, item := range items {
+		var err error
+		if raw[i], err = encodeItem(item); err != nil {
+			return nil, fmt.Errorf("item %d: %w", i, err)
+		}
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalItems decodes a JSON array of items. Items with a registered type
+// are decoded as *GenericItem[T] of that type; others as
+// *GenericItem[json.RawMessage], leaving the value for the caller to decode.
+// Items with an exact weight are decoded as the matching *ExactItem.
+func UnmarshalItems(data []byte) ([]Item, error) {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	items := make([]Item, len(raw))
+	for i, r := range raw {
+		j, err := parseItemJSON(r)
+		if err != nil {
+			return nil, fmt.Errorf("item %d: %w", i, err)
+		}
+		decode := decodeTypedItem[json.RawMessage]
+		if j.Type != "" {
+			var ok bool
+			if decode, ok = itemTypeDecoder(j.Type); !ok {
+				return nil, fmt.Errorf("item %d: unknown item type %q", i, j.Type)
+			}
+		}
+		if items[i], err = decode(j); err != nil {
+			return nil, fmt.Errorf("item %d: %w", i, err)
+		}
+	}
+	return items, nil
+}
+
+// encodeItem encodes an item with its own MarshalJSON if it has one, and
+// with marshalItem otherwise.
+func encodeItem(item Item) ([]byte, error) {
+	if m, ok := item.(json.Marshaler); ok {
+		return m.MarshalJSON()
+	}
+	return marshalItem(item)
+}
+
+// marshalItem encodes any Item.
+func marshalItem(item Item) ([]byte, error) {
+	b := BaseItem{weight: item.Weight(), supply: item.Supply()}
+	if id, ok := item.(Identifier); ok {
+		b.id = id.ID()
+	}
+	if t, ok := item.(Tagger); ok {
+		b.SetTags(t.Tags()...)
+	}
+	var exact *big.Rat
+	if e, ok := item.(ExactWeighter); ok {
+		exact = e.ExactWeight()
+	}
+	value := item.Any()
+	typeName := ""
+	if value != nil {
+		typeName = itemTypeName(reflect.TypeOf(value))
+	}
+	return b.encode(value, typeName, exact)
+}
+
+// encode encodes the item with the given value, type name and exact weight.
+func (i BaseItem) encode(value any, typeName string, exact *big.Rat) ([]byte, error) {
+	if err := checkWeight(i.weight); err != nil {
+		return nil, err
+	}
+	j := itemJSON{
+		ID:     i.id,
+		Type:   typeName,
+		Weight: &i.weight,
+		Supply: &i.supply,
+	}
+	if i.extra != nil {
+		j.Tags, j.Metadata = i.extra.tags, i.extra.metadata
+	}
+	if value != nil {
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		j.Value = raw
+	}
+	if exact != nil {
+		if exact.Sign() < 0 {
+			return nil, fmt.Errorf("weights must be non-negative")
+		}
+		j.ExactWeight = exact.RatString()
+	}
+	return json.Marshal(j)
+}
+
+// decode sets the fields of the item from its encoding.
+func (i *BaseItem) decode(j itemJSON) error {
+	*i = BaseItem{weight: 1, supply: 1, id: j.ID}
+	if j.Tags != nil || j.Metadata != nil {
+		i.extra = &itemExtra{tags: j.Tags, metadata: j.Metadata}
+	}
+	if j.Weight != nil {
+		i.weight = *j.Weight
+	}
+	if j.ExactWeight != "" {
+		exact, err := parseExactWeight(j.ExactWeight)
+		if err != nil {
+			return err
+		}
+		f, _ := exact.Float64()
+		if j.Weight != nil && *j.Weight != f {
+			return fmt.Errorf("weight %v does not match exact weight %s", *j.Weight, j.ExactWeight)
+		}
+		i.weight = f
+	}
+	if j.Supply != nil {
+		i.supply = *j.Supply
+	}
+	return checkWeight(i.weight)
+}
+
+func parseItemJSON(data []byte) (itemJSON, error) {
+	var j itemJSON
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.DisallowUnknownFields()
+	if err := d.Decode(&j); err != nil {
+		return itemJSON{}, fmt.Errorf("invalid item: %w", err)
+	}
+	return j, nil
+}
+
+func parseExactWeight(s string) (*big.Rat, error) {
+	exact, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return nil, fmt.Errorf("invalid exact weight %q", s)
+	}
+	if exact.Sign() < 0 {
+		return nil, fmt.Errorf("weights must be non-negative")
+	}
+	return exact, nil
+}
+
+func checkWeight(w float64) error {
+	if math.IsNaN(w) || math.IsInf(w, 0) {
+		return fmt.Errorf("weights must be finite")
+	}
+	if w < 0 {
+		return fmt.Errorf("weights must be non-negative")
+	}
+	return nil
+}
+
+func (i BaseItem) MarshalJSON() ([]byte, error) {
+	return i.encode(nil, "", nil)
+}
+
+func (i *BaseItem) UnmarshalJSON(data []byte) error {
+	j, err := parseItemJSON(data)
+	if err != nil {
+		return err
+	}
+	if j.Value != nil || j.Type != "" || j.ExactWeight != "" {
+		return fmt.Errorf("base item cannot have a value, type or exact weight")
+	}
+	return i.decode(j)
+}
+
+func (i *GenericItem[T]) MarshalJSON() ([]byte, error) {
+	return i.BaseItem.encode(i.value, itemTypeName(reflect.TypeFor[T]()), nil)
+}
+
+func (i *GenericItem[T]) UnmarshalJSON(data []byte) error {
+	j, err := parseItemJSON(data)
+	if err != nil {
+		return err
+	}
+	if j.ExactWeight != "" {
+		return fmt.Errorf("item with an exact weight must be decoded as an ExactItem")
+	}
+	return i.decode(j)
+}
+
+// decode sets the fields of the item from its encoding, decoding the value
+// as a T.
+func (i *GenericItem[T]) decode(j itemJSON) error {
+	if j.Type != "" {
+		if name := itemTypeName(reflect.TypeFor[T]()); name != j.Type {
+			return fmt.Errorf("item of type %q cannot be decoded as %s", j.Type, reflect.TypeFor[T]())
+		}
+	}
+	if err := i.BaseItem.decode(j); err != nil {
+		return err
+	}
+	var value T
+	if j.Value != nil {
+		if err := json.Unmarshal(j.Value, &value); err != nil {
+			return fmt.Errorf("invalid item value: %w", err)
+		}
+	}
+	i.value = value
+	return nil
+}
+
+func (i *ExactItem[T]) MarshalJSON() ([]byte, error) {
+	return i.BaseItem.encode(i.value, itemTypeName(reflect.TypeFor[T]()), i.exact)
+}
+
+func (i *ExactItem[T]) UnmarshalJSON(data []byte) error {
+	j, err := parseItemJSON(data)
+	if err != nil {
+		return err
+	}
+	if j.ExactWeight == "" {
+		return fmt.Errorf("exact item must have an exact weight")
+	}
+	if err := i.GenericItem.decode(j); err != nil {
+		return err
+	}
+	i.exact, err = parseExactWeight(j.ExactWeight)
+	return err
+}
@@ -0,0 +1,239 @@
+package randomness
+
+import (
+	"encoding/json"
+	"math"
+	"math/big"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type codecWeapon struct {
+	Name   string `json:"name"`
+	Damage int    `json:"damage"`
+}
+
+var _ = RegisterItemType[codecWeapon]("codec-weapon")
+
+// codecItem is an Item without its own JSON encoding.
+type codecItem struct{}
+
+func (codecItem) Weight() float64 { return 1.5 }
+func (codecItem) Supply() int     { return -1 }
+func (codecItem) Any() any        { return 4 }
+
+func TestBaseItemJSON(t *testing.T) {
+	constructors := map[string]BaseItem{
+		"SingleItem":           SingleItem(),
+		"InfiniteSingle":       InfiniteSingle(),
+		"InfiniteItem":         InfiniteItem(3),
+		"FiniteItem":           FiniteItem(4),
+		"WeightedSingleItem":   WeightedSingleItem(2.5),
+		"WeightedInfiniteItem": WeightedInfiniteItem(0.5),
+		"WeightedFiniteItem":   WeightedFiniteItem(1.5, 7),
+		"NewBaseItem":          NewBaseItem(0, -2),
+	}
+	for name, item := range constructors {
+		data, err := json.Marshal(item)
+		if err != nil {
+			t.Fatalf("%s: Marshal() error = %v", name, err)
+		}
+		var decoded BaseItem
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatalf("%s: Unmarshal(%s) error = %v", name, data, err)
+		}
+		if decoded.Weight() != item.Weight() || decoded.Supply() != item.Supply() {
+			t.Errorf("%s: round trip = %v/%d, want %v/%d", name, decoded.Weight(), decoded.Supply(), item.Weight(), item.Supply())
+		}
+		again, _ := json.Marshal(decoded)
+		if string(again) != string(data) {
+			t.Errorf("%s: re-encoded as %s, want %s", name, again, data)
+		}
+	}
+}
+
+func TestGenericItemJSON(t *testing.T) {
+	// A JSON number decodes as the supply.
+	var item GenericItem[string]
+	if err := json.Unmarshal([]byte(`{"value": "x", "weight": 2, "supply": 5}`), &item); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if item.Value() != "x" || item.Weight() != 2 || item.Supply() != 5 {
+		t.Errorf("Unmarshal() = %q/%v/%d, want x/2/5", item.Value(), item.Weight(), item.Supply())
+	}
+
+	// Missing weights and supplies default to 1.
+	if err := json.Unmarshal([]byte(`{}`), &item); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if item.Value() != "" || item.Weight() != 1 || item.Supply() != 1 {
+		t.Errorf("Unmarshal({}) = %q/%v/%d, want empty/1/1", item.Value(), item.Weight(), item.Supply())
+	}
+
+	// Struct values, IDs, tags and metadata survive a round trip.
+	original := NewTaggedItem(codecWeapon{"sword", 12}, 0.25, -1, "sword-1", "legendary", "weapon")
+	original.SetMetadata("art", "sword.png")
+	data, err := json.Marshal(original)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if !strings.Contains(string(data), `"type":"codec-weapon"`) {
+		t.Errorf("Marshal() = %s, want the registered type", data)
+	}
+	var decoded GenericItem[codecWeapon]
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if decoded.Value() != original.Value() || decoded.Weight() != 0.25 || decoded.Supply() != -1 ||
+		decoded.ID() != "sword-1" || !reflect.DeepEqual(decoded.Tags(), original.Tags()) ||
+		!reflect.DeepEqual(decoded.Metadata(), original.Metadata()) {
+		t.Errorf("round trip = %+v, want %+v", decoded, original)
+	}
+}
+
+func TestExactItemJSON(t *testing.T) {
+	original := NewExactItem("jackpot", big.NewRat(1, 3), -1)
+	data, err := json.Marshal(original)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	var decoded ExactItem[string]
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if decoded.ExactWeight().Cmp(big.NewRat(1, 3)) != 0 || decoded.Weight() != original.Weight() || decoded.Value() != "jackpot" {
+		t.Errorf("round trip = %s/%v/%q, want 1/3", decoded.ExactWeight(), decoded.Weight(), decoded.Value())
+	}
+
+	var generic GenericItem[string]
+	if err := json.Unmarshal(data, &generic); err == nil {
+		t.Error("GenericItem decoded an exact weight")
+	}
+}
+
+func TestMarshalItems(t *testing.T) {
+	items := []Item{
+		NewGenericItem(codecWeapon{"axe", 7}, 2, 3),
+		NewExactItem("gem", big.NewRat(2, 7), 1),
+		codecItem{},
+	}
+	data, err := MarshalItems(items)
+	if err != nil {
+		t.Fatalf("MarshalItems() error = %v", err)
+	}
+	decoded, err := UnmarshalItems(data)
+	if err != nil {
+		t.Fatalf("UnmarshalItems() error = %v", err)
+	}
+
+	weapon, ok := decoded[0].(*GenericItem[codecWeapon])
+	if !ok || weapon.Value() != (codecWeapon{"axe", 7}) || weapon.Supply() != 3 {
+		t.Errorf("UnmarshalItems()[0] = %#v, want the registered type", decoded[0])
+	}
+	gem, ok := decoded[1].(*ExactItem[json.RawMessage])
+	if !ok || gem.ExactWeight().Cmp(big.NewRat(2, 7)) != 0 || string(gem.Value()) != `"gem"` {
+		t.Errorf("UnmarshalItems()[1] = %#v, want an exact item", decoded[1])
+	}
+	other, ok := decoded[2].(*GenericItem[json.RawMessage])
+	if !ok || string(other.Value()) != "4" || other.Weight() != 1.5 || other.Supply() != -1 {
+		t.Errorf("UnmarshalItems()[2] = %#v, want a raw item", decoded[2])
+	}
+
+	again, err := MarshalItems(decoded)
+	if err != nil {
+		t.Fatalf("MarshalItems() error = %v", err)
+	}
+	if string(again) != string(data) {
+		t.Errorf("MarshalItems() after round trip = %s, want %s", again, data)
+	}
+}
+
+func TestItemJSONValidation(t *testing.T) {
+	invalid := []string{
+		`{"weight": -1}`,
+		`{"supply": 1.5}`,
+		`{"wieght": 2}`,
+		`{"value": 1, "weight": 1}`,
+		`{"exactWeight": "1/3"}`,
+		`{"weight": 1e400}`,
+	}
+	for _, data := range invalid {
+		var item GenericItem[string]
+		if err := json.Unmarshal([]byte(data), &item); err == nil {
+			t.Errorf("Unmarshal(%s) did not return an error", data)
+		}
+	}
+
+	if _, err := json.Marshal(NewGenericItem("nan", math.NaN(), 1)); err == nil {
+		t.Error("Marshal() accepted a NaN weight")
+	}
+	if _, err := json.Marshal(NewGenericItem("negative", -1, 1)); err == nil {
+		t.Error("Marshal() accepted a negative weight")
+	}
+	if _, err := UnmarshalItems([]byte(`[{"type": "unknown"}]`)); err == nil {
+		t.Error("UnmarshalItems() accepted an unknown type")
+	}
+	if _, err := UnmarshalItems([]byte(`[{"weight": 0.5, "exactWeight": "1/3"}]`)); err == nil {
+		t.Error("UnmarshalItems() accepted a weight that does not match its exact weight")
+	}
+	var weapon GenericItem[codecWeapon]
+	if err := json.Unmarshal([]byte(`{"type": "other", "value": {}}`), &weapon); err == nil {
+		t.Error("Unmarshal() accepted a mismatched type")
+	}
+	if err := RegisterItemType[codecWeapon]("codec-weapon"); err == nil {
+		t.Error("RegisterItemType() registered a type twice")
+	}
+}
+
+func TestRegisterItemTypeConcurrently(t *testing.T) {
+	type concurrentValue struct{ N int }
+	item := NewGenericItem(concurrentValue{1}, 1, 1)
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		RegisterItemType[concurrentValue]("concurrent-value")
+	}()
+	go func() {
+		defer wg.Done()
+		if _, err := MarshalItems([]Item{item}); err != nil {
+			t.Errorf("MarshalItems() error = %v", err)
+		}
+	}()
+	wg.Wait()
+}
+
+// valueItem is an Item used by value, e.g. as a map key.
+type valueItem struct {
+	BaseItem
+	name string
+}
+
+func (i valueItem) Any() any { return i.name }
+
+func TestBaseItemIsComparable(t *testing.T) {
+	if any(SingleItem()) != any(SingleItem()) {
+		t.Error("SingleItem() != SingleItem()")
+	}
+	sword := valueItem{SingleItem(), "sword"}
+	sword.SetTags("weapon")
+	counts := map[Item]int{sword: 1, valueItem{SingleItem(), "shield"}: 2}
+	if counts[sword] != 1 {
+		t.Errorf("counts[sword] = %d, want 1", counts[sword])
+	}
+
+	// A copy does not see the tags and metadata later set on the original.
+	a := SingleItem()
+	a.SetTags("rare")
+	b := a
+	a.SetTags("common")
+	a.SetMetadata("art", "a.png")
+	if tags := b.Tags(); len(tags) != 1 || tags[0] != "rare" || b.Metadata() != nil {
+		t.Errorf("copy has tags %v and metadata %v, want [rare] and none", tags, b.Metadata())
+	}
+	if a == b {
+		t.Error("items with different tags compare equal")
+	}
+}
@@ -0,0 +1,395 @@
+package randomness
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+)
+
+// LootTables is a set of named loot tables whose entries can drop items,
+// nothing, or roll other tables. It is usually loaded with ParseLootTables:
+//
+//	{
+//	  "tables": {
+//	    "chest": {
+//	      "guaranteed": [{"item": "gold", "quantity": {"min": 10, "max": 50}}],
+//	      "rolls": {"min": 1, "max": 2},
+//	      "bonusChance": 0.1,
+//	      "entries": [
+//	        {"table": "gems", "weight": 1},
+//	        {"item": "potion", "weight": 3},
+//	        {"nothing": true, "weight": 6}
+//	      ]
+//	    },
+//	    "gems": {"entries": [{"item": "ruby", "weight": 1}, {"item": "emerald", "weight": 2}]}
+//	  }
+//	}
+//
+// The same fields are tagged for YAML.
+type LootTables struct {
+	Tables map[string]*LootTable `json:"tables" yaml:"tables"`
+}
+
+// LootTable is a table of weighted entries.
+type LootTable struct {
+	// Guaranteed entries are evaluated on every roll of the table, in
+	// order, before the weighted entries.
+	Guaranteed []LootEntry `json:"guaranteed,omitempty" yaml:"guaranteed,omitempty"`
+
+	// Rolls is the number of weighted entries selected. It defaults to 1.
+	Rolls LootRange `json:"rolls,omitzero" yaml:"rolls,omitempty"`
+
+	// BonusChance is the probability of one extra roll.
+	BonusChance float64 `json:"bonusChance,omitempty" yaml:"bonusChance,omitempty"`
+
+	// Entries are selected by weight, with replacement.
+	Entries []LootEntry `json:"entries,omitempty" yaml:"entries,omitempty"`
+}
+
+// LootEntry is an outcome of a loot table. Exactly one of Item, Table and
+// Nothing is set.
+type LootEntry struct {
+	// Item is the name of the item dropped.
+	Item string `json:"item,omitempty" yaml:"item,omitempty"`
+
+	// Table is the name of a table that is rolled instead.
+	Table string `json:"table,omitempty" yaml:"table,omitempty"`
+
+	// Nothing drops nothing.
+	Nothing bool `json:"nothing,omitempty" yaml:"nothing,omitempty"`
+
+	// Weight is the relative weight of the entry. It is ignored for
+	// guaranteed entries.
+	Weight float64 `json:"weight,omitempty" yaml:"weight,omitempty"`
+
+	// Quantity is the number of items dropped, or the number of times the
+	// table is rolled. It defaults to 1.
+	Quantity LootRange `json:"quantity,omitzero" yaml:"quantity,omitempty"`
+}
+
+// LootRange is an inclusive range of counts. A bound that is not set takes
+// the value of the other, so {"min": 5} is exactly 5, and the zero value,
+// with neither set, is exactly 1.
+type LootRange struct {
+	Min *int `json:"min,omitempty" yaml:"min,omitempty"`
+	Max *int `json:"max,omitempty" yaml:"max,omitempty"`
+}
+
+// NewLootRange returns the range [min, max].
+func NewLootRange(min, max int) LootRange {
+	return LootRange{Min: &min, Max: &max}
+}
+
+func (q LootRange) bounds() (int, int) {
+	switch {
+	case q.Min == nil && q.Max == nil:
+		return 1, 1
+	case q.Min == nil:
+		return *q.Max, *q.Max
+	case q.Max == nil:
+		return *q.Min, *q.Min
+	}
+	return *q.Min, *q.Max
+}
+
+// LootDrop is a quantity of an item dropped by a roll.
+type LootDrop struct {
+	Item     string `json:"item"`
+	Quantity int    `json:"quantity"`
+}
+
+// LootStep is a single step of the evaluation of a roll.
+type LootStep struct {
+	Table string `json:"table"` // The table being evaluated
+	Depth int    `json:"depth"` // Nesting depth, 0 for the table rolled
+
+	// Event is "guaranteed" or "entry" for an entry being evaluated,
+	// "rolls" for the number of rolls, "bonus" for the bonus roll chance
+	// and "quantity" for the quantity of an entry.
+	Event string `json:"event"`
+
+	Entry       int     `json:"entry"`                 // Index of the entry, -1 for events of the table
+	Outcome     string  `json:"outcome,omitempty"`     // Item, table or "nothing"
+	Value       int     `json:"value,omitempty"`       // Number of rolls or quantity
+	Probability float64 `json:"probability,omitempty"` // Chance of the entry, or the roll against the bonus chance
+}
+
+// LootResult is the outcome of a roll.
+type LootResult struct {
+	Drops []LootDrop `json:"drops"`
+	Trace []LootStep `json:"trace"`
+}
+
+// Totals returns the total quantity dropped of each item.
+func (l *LootResult) Totals() map[string]int {
+	totals := make(map[string]int)
+	for _, drop := range l.Drops {
+		totals[drop.Item] += drop.Quantity
+	}
+	return totals
+}
+
+// ParseLootTables parses and validates loot tables in JSON.
+func ParseLootTables(data []byte) (*LootTables, error) {
+	return ParseLootTablesWith(data, json.Unmarshal)
+}
+
+// ParseLootTablesWith parses and validates loot tables with the given
+// unmarshal function, such as yaml.Unmarshal from a YAML package.
+func ParseLootTablesWith(data []byte, unmarshal func([]byte, any) error) (*LootTables, error) {
+	var t LootTables
+	if err := unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("cannot parse loot tables: %w", err)
+	}
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// Validate checks that every entry is well formed, that every referenced
+// table exists and that no table refers back to itself.
+func (t *LootTables) Validate() error {
+	names := make([]string, 0, len(t.Tables))
+	for name := range t.Tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		table := t.Tables[name]
+		if table == nil {
+			return fmt.Errorf("loot table %q is empty", name)
+		}
+		if err := checkRange(table.Rolls); err != nil {
+			return fmt.Errorf("loot table %q rolls: %w", name, err)
+		}
+		if c := table.BonusChance; c < 0 || c > 1 || math.IsNaN(c) {
+			return fmt.Errorf("loot table %q bonus chance %v must be in [0, 1]", name, c)
+		}
+		if len(table.Entries) == 0 && len(table.Guaranteed) == 0 {
+			return fmt.Errorf("loot table %q has no entries", name)
+		}
+		total := 0.0
+		for i, entry := range table.Entries {
+			if err := t.checkEntry(entry); err != nil {
+				return fmt.Errorf("loot table %q entry %d: %w", name, i, err)
+			}
+			if w := entry.Weight; w < 0 || math.IsNaN(w) || math.IsInf(w, 0) {
+				return fmt.Errorf("loot table %q entry %d: weights must be non-negative and finite", name, i)
+			}
+			total += entry.Weight
+		}
+		if len(table.Entries) > 0 && total == 0 {
+			return fmt.Errorf("loot table %q has no entries with a positive weight", name)
+		}
+		for i, entry := range table.Guaranteed {
+			if err := t.checkEntry(entry); err != nil {
+				return fmt.Errorf("loot table %q guaranteed entry %d: %w", name, i, err)
+			}
+		}
+	}
+
+	// Walk the references depth first to find cycles.
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[string]int)
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch state[name] {
+		case visiting:
+			return fmt.Errorf("loot table %q refers to itself through %v", name, append(path, name))
+		case done:
+			return nil
+		}
+		state[name] = visiting
+		table := t.Tables[name]
+		for _, entry := range slices.Concat(table.Guaranteed, table.Entries) {
+			if entry.Table != "" {
+				if err := visit(entry.Table, append(path, name)); err != nil {
+					return err
+				}
+			}
+		}
+		state[name] = done
+		return nil
+	}
+	for _, name := range names {
+		if err := visit(name, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *LootTables) checkEntry(entry LootEntry) error {
+	kinds := 0
+	for _, set := range []bool{entry.Item != "", entry.Table != "", entry.Nothing} {
+		if set {
+			kinds++
+		}
+	}
+	if kinds != 1 {
+		return fmt.Errorf("exactly one of item, table and nothing must be set")
+	}
+	if entry.Table != "" {
+		if _, ok := t.Tables[entry.Table]; !ok {
+			return fmt.Errorf("unknown loot table %q", entry.Table)
+		}
+	}
+	return checkRange(entry.Quantity)
+}
+
+func checkRange(q LootRange) error {
+	lo, hi := q.bounds()
+	if lo < 0 || hi < lo {
+		return fmt.Errorf("invalid range [%d, %d]", lo, hi)
+	}
+	return nil
+}
+
+// Roll rolls the named table. A roll of a table consumes the stream in a
+// fixed order:
+//
+//  1. Each guaranteed entry is evaluated in order.
+//  2. If the table has weighted entries and the rolls are a range, the
+//     number of rolls is drawn uniformly from it with the rejection sampling
+//     of SamplingRejection.
+//  3. If the table has weighted entries and a bonus chance, a Probability is
+//     read and a bonus roll is made if it does not exceed the chance.
+//  4. For each roll an entry is selected by weight with a Selection of one
+//     from the entries with infinite supply, and evaluated.
+//
+// Evaluating an entry with a quantity range first draws the quantity
+// uniformly from it as in step 2, then drops that many of the item, or
+// rolls the table that many times, one roll after another.
+func (t *LootTables) Roll(r Randomness, table string) (*LootResult, error) {
+	if _, ok := t.Tables[table]; !ok {
+		return nil, fmt.Errorf("unknown loot table %q", table)
+	}
+	result := &LootResult{}
+	if err := t.roll(r, table, 0, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (t *LootTables) roll(r Randomness, name string, depth int, result *LootResult) error {
+	table := t.Tables[name]
+	for i, entry := range table.Guaranteed {
+		result.Trace = append(result.Trace, LootStep{Table: name, Depth: depth, Event: "guaranteed", Entry: i, Outcome: entry.outcome()})
+		if err := t.evaluate(r, name, i, entry, depth, result); err != nil {
+			return err
+		}
+	}
+	if len(table.Entries) == 0 {
+		return nil
+	}
+
+	rolls, err := drawRange(r, table.Rolls)
+	if err != nil {
+		return err
+	}
+	if lo, hi := table.Rolls.bounds(); lo != hi {
+		result.Trace = append(result.Trace, LootStep{Table: name, Depth: depth, Event: "rolls", Entry: -1, Value: rolls})
+	}
+	if table.BonusChance > 0 {
+		p, err := r.Probability()
+		if err != nil {
+			return err
+		}
+		bonus := p <= table.BonusChance
+		step := LootStep{Table: name, Depth: depth, Event: "bonus", Entry: -1, Probability: p}
+		if bonus {
+			rolls++
+			step.Value = 1
+		}
+		result.Trace = append(result.Trace, step)
+	}
+	if rolls == 0 {
+		return nil
+	}
+
+	items := make([]Item, len(table.Entries))
+	total := 0.0
+	for i, entry := range table.Entries {
+		items[i] = NewGenericItem(i, entry.Weight, -1)
+		total += entry.Weight
+	}
+	for range rolls {
+		results, err := r.Selection(SelectionConfig{Items: items, Count: 1})
+		if err != nil {
+			return err
+		}
+		i := results[0].Any().(int)
+		entry := table.Entries[i]
+		result.Trace = append(result.Trace, LootStep{
+			Table:       name,
+			Depth:       depth,
+			Event:       "entry",
+			Entry:       i,
+			Outcome:     entry.outcome(),
+			Probability: entry.Weight / total,
+		})
+		if err := t.evaluate(r, name, i, entry, depth, result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// evaluate draws the quantity of an entry and drops it.
+func (t *LootTables) evaluate(r Randomness, name string, i int, entry LootEntry, depth int, result *LootResult) error {
+	if entry.Nothing {
+		return nil
+	}
+	quantity, err := drawRange(r, entry.Quantity)
+	if err != nil {
+		return err
+	}
+	if lo, hi := entry.Quantity.bounds(); lo != hi {
+		result.Trace = append(result.Trace, LootStep{Table: name, Depth: depth, Event: "quantity", Entry: i, Outcome: entry.outcome(), Value: quantity})
+	}
+	if entry.Item != "" {
+		if quantity > 0 {
+			result.Drops = append(result.Drops, LootDrop{Item: entry.Item, Quantity: quantity})
+		}
+		return nil
+	}
+	for range quantity {
+		if err := t.roll(r, entry.Table, depth+1, result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e LootEntry) outcome() string {
+	switch {
+	case e.Item != "":
+		return e.Item
+	case e.Table != "":
+		return e.Table
+	default:
+		return "nothing"
+	}
+}
+
+// drawRange draws a count uniformly from a range, reading nothing if the
+// range holds a single count.
+func drawRange(r Randomness, q LootRange) (int, error) {
+	lo, hi := q.bounds()
+	if lo == hi {
+		return lo, nil
+	}
+	n, err := uniform(r, uint64(hi-lo+1))
+	if err != nil {
+		return 0, err
+	}
+	return lo + int(n), nil
+}
@@ -0,0 +1,204 @@
+package randomness
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const lootJSON = `{
+  "tables": {
+    "chest": {
+      "guaranteed": [{"item": "gold", "quantity": {"min": 10, "max": 50}}],
+      "rolls": {"min": 1, "max": 2},
+      "bonusChance": 0.1,
+      "entries": [
+        {"table": "gems", "weight": 1},
+        {"item": "potion", "weight": 3},
+        {"nothing": true, "weight": 6}
+      ]
+    },
+    "gems": {"entries": [{"item": "ruby", "weight": 1}, {"item": "emerald", "weight": 2}]}
+  }
+}`
+
+func TestLootTables(t *testing.T) {
+	tables, err := ParseLootTables([]byte(lootJSON))
+	if err != nil {
+		t.Fatalf("ParseLootTables() error = %v", err)
+	}
+
+	r, _ := NewRandomnessV(AlgorithmLatest, BetaBytes("loot"))
+	const rounds = 20000
+	entries := make(map[string]int)
+	rolls, bonuses := 0, 0
+	for range rounds {
+		result, err := tables.Roll(r, "chest")
+		if err != nil {
+			t.Fatalf("Roll() error = %v", err)
+		}
+		if gold := result.Totals()["gold"]; gold < 10 || gold > 50 {
+			t.Fatalf("Roll() dropped %d gold, want 10 to 50", gold)
+		}
+		for _, step := range result.Trace {
+			switch {
+			case step.Event == "entry" && step.Table == "chest":
+				entries[step.Outcome]++
+				rolls++
+			case step.Event == "entry" && step.Table == "gems" && step.Depth != 1:
+				t.Errorf("gems rolled at depth %d, want 1", step.Depth)
+			case step.Event == "bonus" && step.Value == 1:
+				bonuses++
+			}
+		}
+	}
+
+	// 1.5 rolls on average plus a 10% bonus roll.
+	if got := float64(rolls) / rounds; math.Abs(got-1.6) > 0.03 {
+		t.Errorf("rolls per chest = %f, want 1.6", got)
+	}
+	if got := float64(bonuses) / rounds; math.Abs(got-0.1) > 0.01 {
+		t.Errorf("bonus rolls per chest = %f, want 0.1", got)
+	}
+	for outcome, want := range map[string]float64{"gems": 0.1, "potion": 0.3, "nothing": 0.6} {
+		if got := float64(entries[outcome]) / float64(rolls); math.Abs(got-want) > 0.015 {
+			t.Errorf("chance of %s = %f, want %f", outcome, got, want)
+		}
+	}
+}
+
+func TestLootTablesAreReproducible(t *testing.T) {
+	tables, err := ParseLootTables([]byte(lootJSON))
+	if err != nil {
+		t.Fatalf("ParseLootTables() error = %v", err)
+	}
+	a, err := tables.Roll(NewRandomness(BetaBytes("test")), "chest")
+	if err != nil {
+		t.Fatalf("Roll() error = %v", err)
+	}
+	b, err := tables.Roll(NewRandomness(BetaBytes("test")), "chest")
+	if err != nil {
+		t.Fatalf("Roll() error = %v", err)
+	}
+	if !reflect.DeepEqual(a, b) {
+		t.Errorf("Roll() = %+v then %+v with the same beta", a, b)
+	}
+	if _, err := tables.Roll(NewRandomness(BetaBytes("test")), "missing"); err == nil {
+		t.Error("Roll() accepted an unknown table")
+	}
+}
+
+func TestParseLootTablesWith(t *testing.T) {
+	called := false
+	unmarshal := func(data []byte, v any) error {
+		called = true
+		return json.Unmarshal(data, v)
+	}
+	if _, err := ParseLootTablesWith([]byte(lootJSON), unmarshal); err != nil || !called {
+		t.Errorf("ParseLootTablesWith() error = %v, called = %v", err, called)
+	}
+
+	fail := errors.New("bad yaml")
+	_, err := ParseLootTablesWith(nil, func([]byte, any) error { return fail })
+	if !errors.Is(err, fail) {
+		t.Errorf("ParseLootTablesWith() error = %v, want %v", err, fail)
+	}
+}
+
+func TestLootTablesValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		tables string
+		want   string
+	}{
+		{"cycle", `{"tables": {"a": {"entries": [{"table": "b", "weight": 1}]}, "b": {"guaranteed": [{"table": "a"}]}}}`, "refers to itself"},
+		{"unknown table", `{"tables": {"a": {"entries": [{"table": "b", "weight": 1}]}}}`, "unknown loot table"},
+		{"two outcomes", `{"tables": {"a": {"entries": [{"item": "x", "nothing": true, "weight": 1}]}}}`, "exactly one"},
+		{"no outcome", `{"tables": {"a": {"entries": [{"weight": 1}]}}}`, "exactly one"},
+		{"bad quantity", `{"tables": {"a": {"entries": [{"item": "x", "weight": 1, "quantity": {"min": 3, "max": 1}}]}}}`, "invalid range"},
+		{"bad bonus", `{"tables": {"a": {"bonusChance": 2, "entries": [{"item": "x", "weight": 1}]}}}`, "bonus chance"},
+		{"zero weight", `{"tables": {"a": {"entries": [{"item": "x"}]}}}`, "positive weight"},
+		{"negative weight", `{"tables": {"a": {"entries": [{"item": "x", "weight": -1}]}}}`, "non-negative"},
+		{"empty", `{"tables": {"a": {}}}`, "no entries"},
+	}
+	for _, tt := range tests {
+		_, err := ParseLootTables([]byte(tt.tables))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ParseLootTables() with %s error = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestLootRange(t *testing.T) {
+	tests := []struct {
+		json   string
+		lo, hi int
+	}{
+		{`{}`, 1, 1},
+		{`{"min": 0, "max": 0}`, 0, 0},
+		{`{"min": 5}`, 5, 5},
+		{`{"max": 3}`, 3, 3},
+		{`{"min": 0, "max": 2}`, 0, 2},
+	}
+	for _, tt := range tests {
+		var q LootRange
+		if err := json.Unmarshal([]byte(tt.json), &q); err != nil {
+			t.Fatalf("Unmarshal(%s) error = %v", tt.json, err)
+		}
+		if err := checkRange(q); err != nil {
+			t.Errorf("checkRange(%s) error = %v", tt.json, err)
+		}
+		if lo, hi := q.bounds(); lo != tt.lo || hi != tt.hi {
+			t.Errorf("bounds(%s) = %d, %d, want %d, %d", tt.json, lo, hi, tt.lo, tt.hi)
+		}
+	}
+	if lo, hi := NewLootRange(0, 0).bounds(); lo != 0 || hi != 0 {
+		t.Errorf("NewLootRange(0, 0).bounds() = %d, %d, want 0, 0", lo, hi)
+	}
+}
+
+func TestLootTableWithoutEntriesReadsNothing(t *testing.T) {
+	tables := &LootTables{Tables: map[string]*LootTable{
+		"a": {
+			Guaranteed:  []LootEntry{{Item: "gold"}},
+			Rolls:       NewLootRange(1, 3),
+			BonusChance: 0.5,
+		},
+	}}
+	if err := tables.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	r := NewRandomness(BetaBytes("test"))
+	result, err := tables.Roll(r, "a")
+	if err != nil {
+		t.Fatalf("Roll() error = %v", err)
+	}
+	if len(result.Trace) != 1 || result.Totals()["gold"] != 1 {
+		t.Errorf("Roll() = %+v, want only the guaranteed gold", result)
+	}
+	got, _ := r.Uint64()
+	want, _ := NewRandomness(BetaBytes("test")).Uint64()
+	if got != want {
+		t.Errorf("Roll() of a table without entries consumed the stream")
+	}
+}
+
+// TestLootTablesYAMLTags checks that every field that can be loaded has a
+// YAML tag naming it as in JSON, so a YAML document with the same structure
+// as lootJSON loads the same tables with ParseLootTablesWith.
+func TestLootTablesYAMLTags(t *testing.T) {
+	for _, v := range []any{LootTables{}, LootTable{}, LootEntry{}, LootRange{}} {
+		typ := reflect.TypeOf(v)
+		for i := range typ.NumField() {
+			field := typ.Field(i)
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			yaml, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+			if yaml != name {
+				t.Errorf("%s.%s has YAML name %q, want %q", typ.Name(), field.Name, yaml, name)
+			}
+		}
+	}
+}
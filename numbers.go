@@ -1,7 +1,10 @@
 // Version 1.0.0
 package randomness
 
-import "fmt"
+import (
+	"encoding/json"
+	"fmt"
+)
 
 // Numbers allows reading a fixed number of unique random numbers in a
 // range [0, magnitude) from a source of random data.
@@ -37,7 +40,7 @@ func NewNumbers(bits BitArray, perNumber, count, magnitude int) Numbers {
 
 // Read returns a random number in the range [0, magnitude).
 func (n *numbers) Read(magnitude int) (int, error) {
-	if n.pos > n.count+1 {
+	if n.pos > n.count+1 || (n.pos+1)*n.perNumber > n.bits.Length() {
 		return 0, fmt.Errorf("out of numbers")
 	}
 	if magnitude > n.magnitude {
@@ -63,3 +66,23 @@ func (n *numbers) Readn(count, magnitude int) (nums []int, err error) {
 	}
 	return nums, nil
 }
+
+// MarshalJSON encodes the numbers that remain to be read at the full
+// magnitude, without reading them:
+//
+//	{"magnitude": 37, "values": [12, 35, 13]}
+func (n *numbers) MarshalJSON() ([]byte, error) {
+	rest := *n
+	values := []int{}
+	for rest.pos <= rest.count {
+		v, err := rest.Read(rest.magnitude)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return json.Marshal(struct {
+		Magnitude int   `json:"magnitude"`
+		Values    []int `json:"values"`
+	}{n.magnitude, values})
+}
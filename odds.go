@@ -0,0 +1,168 @@
+package randomness
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/big"
+)
+
+// maxOddsStates bounds the number of distinct selection states CalculateOdds
+// will track for a single draw.
+const maxOddsStates = 1 << 20
+
+// Odds are the exact theoretical probabilities of the outcomes of a
+// selection, computed without consuming randomness. They are the odds of an
+// exact selection (see SelectionConfig.Exact); the float64 selections match
+// them up to floating point rounding.
+type Odds struct {
+	Items []Item
+
+	// Positions[d][i] is the probability that draw d selects item i.
+	Positions [][]*big.Rat
+
+	// Counts[i][k] is the probability that item i is selected exactly k
+	// times over all the draws.
+	Counts [][]*big.Rat
+}
+
+// Position returns the probability that draw d selects item i as a float64.
+func (o *Odds) Position(d, i int) float64 {
+	f, _ := o.Positions[d][i].Float64()
+	return f
+}
+
+// Count returns the probability that item i is selected exactly k times as a
+// float64.
+func (o *Odds) Count(i, k int) float64 {
+	if k < 0 || k >= len(o.Counts[i]) {
+		return 0
+	}
+	f, _ := o.Counts[i][k].Float64()
+	return f
+}
+
+// Expected returns the expected number of times item i is selected.
+func (o *Odds) Expected(i int) *big.Rat {
+	sum := new(big.Rat)
+	for d := range o.Positions {
+		sum.Add(sum, o.Positions[d][i])
+	}
+	return sum
+}
+
+// oddsState is a distinct outcome of the draws so far: how many times each
+// item has been selected, and its probability.
+type oddsState struct {
+	counts []int
+	p      *big.Rat
+}
+
+// CalculateOdds computes the exact probability of each item being selected
+// at each draw of cfg, and the distribution of the number of times each item
+// is selected, for finite, infinite and mixed supplies. If cfg.ItemStates is
+// set, the odds start from the supply remaining in it.
+//
+// The calculation tracks every reachable combination of selection counts, so
+// it is intended for disclosure-sized configurations; it returns an error if
+// more than 2^20 combinations are reachable at any draw.
+func CalculateOdds(cfg SelectionConfig) (*Odds, error) {
+	if err := ValidateSelectionConfig(cfg); err != nil {
+		return nil, err
+	}
+	if cfg.constrained() {
+		return nil, fmt.Errorf("cannot calculate the odds of a constrained selection")
+	}
+	states := cfg.ItemStates
+	if states == nil {
+		states = newItemStates(cfg.Items)
+	}
+
+	weights := make([]*big.Rat, len(states))
+	units := make([]int, len(states))
+	for i := range states {
+		w, err := exactWeight(states[i].Item)
+		if err != nil {
+			return nil, err
+		}
+		weights[i] = w
+		units[i] = states[i].units()
+	}
+
+	odds := &Odds{
+		Items:     make([]Item, len(states)),
+		Positions: make([][]*big.Rat, cfg.Count),
+		Counts:    make([][]*big.Rat, len(states)),
+	}
+	for i := range states {
+		odds.Items[i] = states[i].Item
+		odds.Counts[i] = make([]*big.Rat, cfg.Count+1)
+		for k := range odds.Counts[i] {
+			odds.Counts[i][k] = new(big.Rat)
+		}
+	}
+
+	current := map[string]*oddsState{
+		oddsKey(make([]int, len(states))): {counts: make([]int, len(states)), p: big.NewRat(1, 1)},
+	}
+	for d := range cfg.Count {
+		odds.Positions[d] = make([]*big.Rat, len(states))
+		for i := range states {
+			odds.Positions[d][i] = new(big.Rat)
+		}
+
+		next := make(map[string]*oddsState)
+		for _, state := range current {
+			spans := make([]*big.Rat, len(states))
+			total := new(big.Rat)
+			for i := range states {
+				available := units[i]
+				if states[i].IsConsumed {
+					available -= state.counts[i]
+				}
+				spans[i] = new(big.Rat).Mul(weights[i], big.NewRat(int64(available), 1))
+				total.Add(total, spans[i])
+			}
+			if total.Sign() == 0 {
+				return nil, fmt.Errorf("no items remaining with non-zero supply at draw %d", d)
+			}
+
+			for i := range states {
+				if spans[i].Sign() == 0 {
+					continue
+				}
+				p := new(big.Rat).Quo(spans[i], total)
+				p.Mul(p, state.p)
+				odds.Positions[d][i].Add(odds.Positions[d][i], p)
+
+				counts := append([]int(nil), state.counts...)
+				counts[i]++
+				key := oddsKey(counts)
+				if s, ok := next[key]; ok {
+					s.p.Add(s.p, p)
+				} else {
+					next[key] = &oddsState{counts: counts, p: p}
+				}
+			}
+			if len(next) > maxOddsStates {
+				return nil, fmt.Errorf("too many outcomes to calculate odds exactly at draw %d", d)
+			}
+		}
+		current = next
+	}
+
+	for _, state := range current {
+		for i, k := range state.counts {
+			odds.Counts[i][k].Add(odds.Counts[i][k], state.p)
+		}
+	}
+	return odds, nil
+}
+
+// oddsKey encodes selection counts as a map key.
+func oddsKey(counts []int) string {
+	var buf []byte
+	for _, c := range counts {
+		buf = binary.AppendUvarint(buf, uint64(c))
+	}
+	return string(buf)
+}
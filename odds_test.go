@@ -0,0 +1,115 @@
+package randomness
+
+import (
+	"math/big"
+	"testing"
+)
+
+func assertRat(t *testing.T, name string, got *big.Rat, want string) {
+	t.Helper()
+	expected, _ := new(big.Rat).SetString(want)
+	if got.Cmp(expected) != 0 {
+		t.Errorf("%s = %s, want %s", name, got.RatString(), expected.RatString())
+	}
+}
+
+func TestCalculateOddsFinite(t *testing.T) {
+	odds, err := CalculateOdds(SelectionConfig{
+		Items: []Item{
+			NewGenericItem("apple", 1, 3),
+			NewGenericItem("orange", 1, 1),
+		},
+		Count: 2,
+	})
+	if err != nil {
+		t.Fatalf("CalculateOdds() error = %v", err)
+	}
+
+	assertRat(t, "P(draw 0 = orange)", odds.Positions[0][1], "1/4")
+	assertRat(t, "P(draw 1 = orange)", odds.Positions[1][1], "1/4")
+	assertRat(t, "P(0 oranges)", odds.Counts[1][0], "1/2")
+	assertRat(t, "P(1 orange)", odds.Counts[1][1], "1/2")
+	assertRat(t, "P(2 oranges)", odds.Counts[1][2], "0")
+	assertRat(t, "P(2 apples)", odds.Counts[0][2], "1/2")
+	assertRat(t, "E(apples)", odds.Expected(0), "3/2")
+}
+
+func TestCalculateOddsInfinite(t *testing.T) {
+	odds, err := CalculateOdds(SelectionConfig{
+		Items: []Item{
+			NewGenericItem("miss", 1, -1),
+			NewGenericItem("hit", 1, -3),
+		},
+		Count: 3,
+	})
+	if err != nil {
+		t.Fatalf("CalculateOdds() error = %v", err)
+	}
+
+	for d := range 3 {
+		assertRat(t, "P(hit)", odds.Positions[d][1], "3/4")
+	}
+	assertRat(t, "P(0 hits)", odds.Counts[1][0], "1/64")
+	assertRat(t, "P(3 hits)", odds.Counts[1][3], "27/64")
+	if odds.Count(1, 3) != 27.0/64 {
+		t.Errorf("Count(1, 3) = %v, want %v", odds.Count(1, 3), 27.0/64)
+	}
+}
+
+func TestCalculateOddsMixed(t *testing.T) {
+	// The example from the Selection documentation.
+	odds, err := CalculateOdds(SelectionConfig{
+		Items: []Item{
+			NewGenericItem("apple", 1, 3),
+			NewGenericItem("orange", 1, -3),
+		},
+		Count: 2,
+	})
+	if err != nil {
+		t.Fatalf("CalculateOdds() error = %v", err)
+	}
+	assertRat(t, "P(draw 0 = orange)", odds.Positions[0][1], "1/2")
+	assertRat(t, "P(draw 1 = orange)", odds.Positions[1][1], "11/20")
+	assertRat(t, "P(2 apples)", odds.Counts[0][2], "1/5")
+}
+
+func TestCalculateOddsExactWeights(t *testing.T) {
+	odds, err := CalculateOdds(SelectionConfig{
+		Items: []Item{
+			NewExactItem("jackpot", big.NewRat(1, 1_000_000_000), -1),
+			NewGenericItem("nothing", 1, -1),
+		},
+		Count: 1,
+	})
+	if err != nil {
+		t.Fatalf("CalculateOdds() error = %v", err)
+	}
+	assertRat(t, "P(jackpot)", odds.Positions[0][0], "1/1000000001")
+}
+
+func TestCalculateOddsFromState(t *testing.T) {
+	s, _ := NewSelector([]Item{
+		NewGenericItem("apple", 1, 2),
+		NewGenericItem("orange", 1, 2),
+	})
+	s.Draw(NewRandomness(BetaBytes("test")), 1)
+
+	odds, err := CalculateOdds(SelectionConfig{Items: s.items, Count: 1, ItemStates: s.states})
+	if err != nil {
+		t.Fatalf("CalculateOdds() error = %v", err)
+	}
+	drawn := 0
+	if n, _ := s.Remaining(1); n == 1 {
+		drawn = 1
+	}
+	assertRat(t, "P(drawn item again)", odds.Positions[0][drawn], "1/3")
+}
+
+func TestCalculateOddsErrors(t *testing.T) {
+	if _, err := CalculateOdds(SelectionConfig{Items: []Item{NewGenericItem("a", 0, 1)}, Count: 1}); err == nil {
+		t.Error("CalculateOdds() accepted a zero total weight")
+	}
+	if _, err := CalculateOdds(SelectionConfig{}); err == nil {
+		t.Error("CalculateOdds() accepted an empty configuration")
+	}
+}
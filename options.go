@@ -0,0 +1,21 @@
+package randomness
+
+// Option configures a Randomness created by NewRandomness.
+type Option func(*randomness)
+
+// WithSampling selects how bounded integers are derived by IntN, Pick and
+// PickDistinct, overriding the default of the algorithm version.
+func WithSampling(s Sampling) Option {
+	return func(b *randomness) {
+		b.sampling = s
+	}
+}
+
+// WithAmplifier selects the amplifier used to extend the beta, overriding
+// the default of the algorithm version. Its identifier is available from
+// Randomness.Amplifier so verifiers know which one to use.
+func WithAmplifier(a Amplifier) Option {
+	return func(b *randomness) {
+		b.amplifier = a
+	}
+}
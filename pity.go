@@ -0,0 +1,327 @@
+package randomness
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+)
+
+// Ramp returns the multiplier applied to the weight of an item on the n-th
+// pull past its soft pity threshold, starting from n = 1.
+type Ramp func(n int) float64
+
+// LinearRamp raises the weight by step times the base weight on every pull
+// past soft pity: the multiplier is 1 + step*n.
+func LinearRamp(step float64) Ramp {
+	return func(n int) float64 {
+		return 1 + step*float64(n)
+	}
+}
+
+// ExponentialRamp multiplies the weight by factor on every pull past soft
+// pity: the multiplier is factor^n.
+func ExponentialRamp(factor float64) Ramp {
+	return func(n int) float64 {
+		return math.Pow(factor, float64(n))
+	}
+}
+
+// PityRule gives an item bad-luck protection. Its counter is the number of
+// pulls since the item was last pulled.
+type PityRule struct {
+	// Item is the index of the protected item.
+	Item int
+
+	// SoftPity is the number of pulls without the item after which its
+	// weight is multiplied by Ramp. Zero disables soft pity.
+	SoftPity int
+
+	// HardPity guarantees the item on this pull without it, so it is
+	// pulled at least once in every HardPity pulls. Zero disables hard pity.
+	HardPity int
+
+	// Ramp is the weight multiplier past soft pity.
+	Ramp Ramp
+}
+
+// PityState is the persisted state of a Pity: the counter of each rule, in
+// rule order.
+type PityState struct {
+	Counters []int `json:"counters"`
+}
+
+// Pity pulls one item at a time from items with infinite supply, applying
+// soft and hard pity rules. Each pull is a Selection of one item with the
+// effective weights of the pull, so it consumes the stream as Selection does.
+// If several items reach hard pity on the same pull, one of them is selected
+// by their base weights.
+type Pity struct {
+	items    []Item
+	rules    []PityRule
+	counters []int
+}
+
+// NewPity creates a Pity with all counters at zero.
+func NewPity(items []Item, rules ...PityRule) (*Pity, error) {
+	if len(items) == 0 {
+		return nil, fmt.Errorf("no items to select from")
+	}
+	total := 0.0
+	for i, item := range items {
+		if item.Supply() >= 0 {
+			return nil, fmt.Errorf("item %d has a finite supply of %d, pity needs infinite supplies", i, item.Supply())
+		}
+		if w := item.Weight(); w < 0 || math.IsNaN(w) || math.IsInf(w, 0) {
+			return nil, fmt.Errorf("weights must be non-negative and finite")
+		}
+		total += item.Weight()
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("total weight must be positive")
+	}
+	for i, rule := range rules {
+		switch {
+		case rule.Item < 0 || rule.Item >= len(items):
+			return nil, fmt.Errorf("rule %d: item %d out of range", i, rule.Item)
+		case items[rule.Item].Weight() == 0:
+			return nil, fmt.Errorf("rule %d: item %d has zero weight", i, rule.Item)
+		case rule.SoftPity < 0 || rule.HardPity < 0:
+			return nil, fmt.Errorf("rule %d: pity thresholds must be non-negative", i)
+		case rule.SoftPity > 0 && rule.HardPity > 0 && rule.SoftPity >= rule.HardPity:
+			return nil, fmt.Errorf("rule %d: soft pity %d must be below hard pity %d", i, rule.SoftPity, rule.HardPity)
+		case rule.SoftPity > 0 && rule.Ramp == nil:
+			return nil, fmt.Errorf("rule %d: soft pity needs a ramp", i)
+		}
+	}
+	return &Pity{
+		items:    slices.Clone(items),
+		rules:    slices.Clone(rules),
+		counters: make([]int, len(rules)),
+	}, nil
+}
+
+// State returns the counters so they can be persisted between sessions.
+func (p *Pity) State() PityState {
+	return PityState{Counters: slices.Clone(p.counters)}
+}
+
+// Restore replaces the counters with a persisted state.
+func (p *Pity) Restore(state PityState) error {
+	if len(state.Counters) != len(p.rules) {
+		return fmt.Errorf("pity state has %d counters, want %d", len(state.Counters), len(p.rules))
+	}
+	for i, c := range state.Counters {
+		if c < 0 {
+			return fmt.Errorf("pity counter %d is negative", i)
+		}
+	}
+	p.counters = slices.Clone(state.Counters)
+	return nil
+}
+
+// MarshalJSON encodes the state of the Pity.
+func (p *Pity) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.State())
+}
+
+// UnmarshalJSON restores a state encoded by MarshalJSON into a Pity created
+// with the same rules.
+func (p *Pity) UnmarshalJSON(data []byte) error {
+	var state PityState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+	return p.Restore(state)
+}
+
+// Pull selects one item with the effective weights of the next pull and
+// updates the counters.
+func (p *Pity) Pull(r Randomness) (SelectionResult, error) {
+	weights, err := p.weights(p.counters)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]Item, len(p.items))
+	for i, item := range p.items {
+		items[i] = &pityItem{Item: item, index: i, weight: weights[i]}
+	}
+	results, err := r.Selection(SelectionConfig{Items: items, Count: 1})
+	if err != nil {
+		return nil, err
+	}
+	result := results[0]
+	pulled := result.Get().(*pityItem).index
+	p.advance(p.counters, pulled)
+	return &selectionResult{
+		Item:        p.items[pulled],
+		instance:    result.Instance(),
+		fraction:    result.Fraction(),
+		index:       pulled,
+		explanation: result.Explanation(),
+	}, nil
+}
+
+// Odds returns the effective probability of each item on the next pull.
+func (p *Pity) Odds() ([]float64, error) {
+	weights, err := p.weights(p.counters)
+	if err != nil {
+		return nil, err
+	}
+	return normalize(weights), nil
+}
+
+// PullOdds is the chance of a protected item on a pull.
+type PullOdds struct {
+	Pull        int     // Pull number counted from the current state, from 1
+	Probability float64 // Chance that this is the first pull to give the item
+	Conditional float64 // Chance of the item on this pull if no earlier pull gave it
+	Cumulative  float64 // Chance that the item was given by this pull
+}
+
+// maxPityStates bounds the number of counter states Schedule tracks.
+const maxPityStates = 1 << 20
+
+// Schedule returns the odds of the item of a rule on each of the next pulls,
+// counted from the current state, so they can be disclosed. The counters of
+// other rules are tracked exactly, as their ramps change the total weight.
+// pulls defaults to the hard pity of the rule when it is zero.
+func (p *Pity) Schedule(rule, pulls int) ([]PullOdds, error) {
+	if rule < 0 || rule >= len(p.rules) {
+		return nil, fmt.Errorf("rule %d out of range", rule)
+	}
+	if pulls == 0 {
+		pulls = p.rules[rule].HardPity
+	}
+	if pulls <= 0 {
+		return nil, fmt.Errorf("number of pulls must be positive")
+	}
+	target := p.rules[rule].Item
+
+	// The distribution of the counters over the histories without the item.
+	type state struct {
+		counters []int
+		p        float64
+	}
+	states := map[string]*state{oddsKey(p.counters): {counters: slices.Clone(p.counters), p: 1}}
+	schedule := make([]PullOdds, 0, pulls)
+	cumulative := 0.0
+	for n := 1; n <= pulls; n++ {
+		next := make(map[string]*state)
+		hit := 0.0
+		for _, s := range states {
+			weights, err := p.weights(s.counters)
+			if err != nil {
+				return nil, err
+			}
+			for i, prob := range normalize(weights) {
+				if prob == 0 {
+					continue
+				}
+				if i == target {
+					hit += s.p * prob
+					continue
+				}
+				counters := slices.Clone(s.counters)
+				p.advance(counters, i)
+				key := oddsKey(counters)
+				if ns, ok := next[key]; ok {
+					ns.p += s.p * prob
+				} else {
+					next[key] = &state{counters: counters, p: s.p * prob}
+				}
+			}
+		}
+		if len(next) > maxPityStates {
+			return nil, fmt.Errorf("pity schedule needs more than %d states", maxPityStates)
+		}
+
+		conditional := 0.0
+		if remaining := 1 - cumulative; remaining > 0 {
+			conditional = min(hit/remaining, 1)
+		}
+		cumulative += hit
+		schedule = append(schedule, PullOdds{
+			Pull:        n,
+			Probability: hit,
+			Conditional: conditional,
+			Cumulative:  min(cumulative, 1),
+		})
+		states = next
+	}
+	return schedule, nil
+}
+
+// weights returns the effective weight of every item on the pull after the
+// given counters.
+func (p *Pity) weights(counters []int) ([]float64, error) {
+	weights := make([]float64, len(p.items))
+	for i, item := range p.items {
+		weights[i] = item.Weight() * float64(-item.Supply())
+	}
+
+	guaranteed := make([]bool, len(p.items))
+	anyGuaranteed := false
+	for i, rule := range p.rules {
+		pull := counters[i] + 1
+		if rule.HardPity > 0 && pull >= rule.HardPity {
+			guaranteed[rule.Item] = true
+			anyGuaranteed = true
+		}
+		if rule.SoftPity > 0 && pull > rule.SoftPity {
+			m := rule.Ramp(pull - rule.SoftPity)
+			if m < 0 || math.IsNaN(m) || math.IsInf(m, 0) {
+				return nil, fmt.Errorf("rule %d: ramp returned %v", i, m)
+			}
+			weights[rule.Item] *= m
+		}
+	}
+	if anyGuaranteed {
+		for i, item := range p.items {
+			if guaranteed[i] {
+				weights[i] = item.Weight() * float64(-item.Supply())
+			} else {
+				weights[i] = 0
+			}
+		}
+	}
+	return weights, nil
+}
+
+// advance updates counters for a pull of item pulled.
+func (p *Pity) advance(counters []int, pulled int) {
+	for i, rule := range p.rules {
+		if rule.Item == pulled {
+			counters[i] = 0
+		} else {
+			counters[i]++
+		}
+	}
+}
+
+// pityItem carries the effective weight of an item for a single pull.
+type pityItem struct {
+	Item
+	index  int
+	weight float64
+}
+
+func (i *pityItem) Weight() float64 {
+	return i.weight
+}
+
+func (i *pityItem) Supply() int {
+	return -1
+}
+
+func normalize(weights []float64) []float64 {
+	total := 0.0
+	for _, w := range weights {
+		total += w
+	}
+	probs := make([]float64, len(weights))
+	for i, w := range weights {
+		probs[i] = w / total
+	}
+	return probs
+}
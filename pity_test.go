@@ -0,0 +1,159 @@
+package randomness
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func pityItems() []Item {
+	return []Item{
+		NewGenericItem("rare", 6, -1),
+		NewGenericItem("common", 994, -1),
+	}
+}
+
+func TestPityHardPity(t *testing.T) {
+	p, err := NewPity(pityItems(), PityRule{Item: 0, HardPity: 10})
+	if err != nil {
+		t.Fatalf("NewPity() error = %v", err)
+	}
+	r, _ := NewRandomnessV(AlgorithmLatest, BetaBytes("pity"))
+	since := 0
+	for range 5000 {
+		result, err := p.Pull(r)
+		if err != nil {
+			t.Fatalf("Pull() error = %v", err)
+		}
+		since++
+		if result.Any() == "rare" {
+			since = 0
+		}
+		if since >= 10 {
+			t.Fatal("Pull() went 10 pulls without the rare item")
+		}
+		if p.State().Counters[0] != since {
+			t.Fatalf("counter = %d, want %d", p.State().Counters[0], since)
+		}
+	}
+}
+
+func TestPityOdds(t *testing.T) {
+	p, err := NewPity(pityItems(), PityRule{Item: 0, SoftPity: 5, HardPity: 10, Ramp: LinearRamp(1)})
+	if err != nil {
+		t.Fatalf("NewPity() error = %v", err)
+	}
+	if err := p.Restore(PityState{Counters: []int{7}}); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	// The 8th pull is the 3rd past soft pity, so the rare weight is 6*4.
+	odds, err := p.Odds()
+	if err != nil {
+		t.Fatalf("Odds() error = %v", err)
+	}
+	if want := 24.0 / 1018; math.Abs(odds[0]-want) > 1e-12 {
+		t.Errorf("Odds()[0] = %v, want %v", odds[0], want)
+	}
+
+	schedule, err := p.Schedule(0, 0)
+	if err != nil {
+		t.Fatalf("Schedule() error = %v", err)
+	}
+	if len(schedule) != 10 {
+		t.Fatalf("Schedule() has %d pulls, want 10", len(schedule))
+	}
+	if schedule[0].Conditional != odds[0] {
+		t.Errorf("Schedule()[0].Conditional = %v, want %v", schedule[0].Conditional, odds[0])
+	}
+	if want := 30.0 / 1024; math.Abs(schedule[1].Conditional-want) > 1e-12 {
+		t.Errorf("Schedule()[1].Conditional = %v, want %v", schedule[1].Conditional, want)
+	}
+	if schedule[2].Conditional != 1 || schedule[2].Cumulative != 1 {
+		t.Errorf("Schedule()[2] = %+v, want the hard pity pull", schedule[2])
+	}
+	if schedule[3].Probability != 0 {
+		t.Errorf("Schedule()[3].Probability = %v, want 0 after hard pity", schedule[3].Probability)
+	}
+}
+
+func TestPityScheduleMatchesPulls(t *testing.T) {
+	p, err := NewPity(pityItems(), PityRule{Item: 0, SoftPity: 20, HardPity: 40, Ramp: ExponentialRamp(1.5)})
+	if err != nil {
+		t.Fatalf("NewPity() error = %v", err)
+	}
+	schedule, err := p.Schedule(0, 0)
+	if err != nil {
+		t.Fatalf("Schedule() error = %v", err)
+	}
+	expected := 0.0
+	for _, s := range schedule {
+		expected += float64(s.Pull) * s.Probability
+	}
+
+	r, _ := NewRandomnessV(AlgorithmLatest, BetaBytes("pity"))
+	total, hits := 0, 0
+	for hits < 4000 {
+		result, err := p.Pull(r)
+		if err != nil {
+			t.Fatalf("Pull() error = %v", err)
+		}
+		total++
+		if result.Any() == "rare" {
+			hits++
+		}
+	}
+	if got := float64(total) / float64(hits); math.Abs(got-expected)/expected > 0.03 {
+		t.Errorf("mean pulls per rare = %f, want %f", got, expected)
+	}
+}
+
+func TestPityState(t *testing.T) {
+	rules := []PityRule{{Item: 0, HardPity: 10}, {Item: 1, SoftPity: 2, Ramp: LinearRamp(0.5)}}
+	p, _ := NewPity(pityItems(), rules...)
+	r, _ := NewRandomnessV(AlgorithmLatest, BetaBytes("pity"))
+	for range 7 {
+		if _, err := p.Pull(r); err != nil {
+			t.Fatalf("Pull() error = %v", err)
+		}
+	}
+	data, err := json.Marshal(p)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	restored, _ := NewPity(pityItems(), rules...)
+	if err := json.Unmarshal(data, restored); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	a, _ := p.Odds()
+	b, _ := restored.Odds()
+	if a[0] != b[0] || a[1] != b[1] {
+		t.Errorf("Odds() after restore = %v, want %v", b, a)
+	}
+
+	if err := restored.Restore(PityState{Counters: []int{1}}); err == nil {
+		t.Error("Restore() accepted the wrong number of counters")
+	}
+	if err := restored.Restore(PityState{Counters: []int{1, -1}}); err == nil {
+		t.Error("Restore() accepted a negative counter")
+	}
+}
+
+func TestNewPityErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		items []Item
+		rule  PityRule
+	}{
+		{"finite supply", []Item{NewGenericItem("a", 1, 3)}, PityRule{}},
+		{"item out of range", pityItems(), PityRule{Item: 2}},
+		{"soft above hard", pityItems(), PityRule{SoftPity: 10, HardPity: 10, Ramp: LinearRamp(1)}},
+		{"soft without ramp", pityItems(), PityRule{SoftPity: 10}},
+		{"zero weight", []Item{NewGenericItem("a", 0, -1), NewGenericItem("b", 1, -1)}, PityRule{HardPity: 3}},
+	}
+	for _, tt := range tests {
+		if _, err := NewPity(tt.items, tt.rule); err == nil {
+			t.Errorf("NewPity() with %s did not return an error", tt.name)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package randomness
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+)
+
+// ServerSeedSize is the number of random bytes in a server seed produced by
+// GenerateServerSeed.
+const ServerSeedSize = 32
+
+// GenerateServerSeed returns a new hex encoded server seed read from
+// crypto/rand.
+func GenerateServerSeed() (string, error) {
+	seed := make([]byte, ServerSeedSize)
+	if _, err := rand.Read(seed); err != nil {
+		return "", fmt.Errorf("cannot generate server seed: %w", err)
+	}
+	return hex.EncodeToString(seed), nil
+}
+
+// CommitServerSeed returns the commitment published to the player before a
+// round is played: the hex encoded SHA-256 of the server seed.
+func CommitServerSeed(serverSeed string) string {
+	sum := sha256.Sum256([]byte(serverSeed))
+	return hex.EncodeToString(sum[:])
+}
+
+// VerifyServerSeed checks that a revealed server seed matches the commitment
+// that was published for it.
+func VerifyServerSeed(serverSeed, commitment string) error {
+	want, err := hex.DecodeString(commitment)
+	if err != nil {
+		return fmt.Errorf("invalid server seed commitment: %w", err)
+	}
+	got := sha256.Sum256([]byte(serverSeed))
+	if subtle.ConstantTimeCompare(got[:], want) != 1 {
+		return fmt.Errorf("server seed does not match commitment %s", commitment)
+	}
+	return nil
+}
+
+// ProvablyFairBeta derives the beta for a single round from the server seed,
+// the client seed and the nonce of the round:
+//
+//	HMAC-SHA512(key = serverSeed, message = clientSeed + ":" + decimal(nonce))
+//
+// The result is 64 bytes long.
+func ProvablyFairBeta(serverSeed, clientSeed string, nonce uint64) BetaBytes {
+	mac := hmac.New(sha512.New, []byte(serverSeed))
+	mac.Write([]byte(clientSeed))
+	mac.Write([]byte{':'})
+	mac.Write([]byte(strconv.FormatUint(nonce, 10)))
+	return BetaBytes(mac.Sum(nil))
+}
+
+// NewProvablyFair creates a new Randomness instance for a single round of the
+// server seed, client seed and nonce scheme. See ProvablyFairBeta.
+func NewProvablyFair(serverSeed, clientSeed string, nonce uint64, opts ...Option) Randomness {
+	return NewRandomness(ProvablyFairBeta(serverSeed, clientSeed, nonce), opts...)
+}
+
+// VerifyProvablyFair checks the revealed server seed against its commitment
+// and, if it matches, returns the Randomness for the round so the player can
+// recompute its outcome.
+func VerifyProvablyFair(serverSeed, commitment, clientSeed string, nonce uint64, opts ...Option) (Randomness, error) {
+	if err := VerifyServerSeed(serverSeed, commitment); err != nil {
+		return nil, err
+	}
+	return NewProvablyFair(serverSeed, clientSeed, nonce, opts...), nil
+}
@@ -0,0 +1,70 @@
+package randomness
+
+import (
+	"testing"
+)
+
+func TestProvablyFairBeta(t *testing.T) {
+	expected := MustBetaBytesFromHex("c2d4f9c6f4f07a59de68e159f136164e0686b02b3c8dd919877ee8a8ae45abde6b87106605e30bb8e855c864a5485ca37c29b05658e3b9e57b5c2a26d7179e32")
+	got := ProvablyFairBeta("server", "client", 1)
+	if got.String() != expected.String() {
+		t.Errorf("ProvablyFairBeta() = %s, want %s", got, expected)
+	}
+
+	if ProvablyFairBeta("server", "client", 2).String() == got.String() {
+		t.Error("ProvablyFairBeta() returned the same beta for different nonces")
+	}
+}
+
+func TestServerSeedCommitment(t *testing.T) {
+	commitment := CommitServerSeed("server")
+	if commitment != "b3eacd33433b31b5252351032c9b3e7a2e7aa7738d5decdf0dd6c62680853c06" {
+		t.Errorf("CommitServerSeed() = %s", commitment)
+	}
+
+	if err := VerifyServerSeed("server", commitment); err != nil {
+		t.Errorf("VerifyServerSeed() error = %v", err)
+	}
+	if err := VerifyServerSeed("other", commitment); err == nil {
+		t.Error("VerifyServerSeed() accepted the wrong server seed")
+	}
+	if err := VerifyServerSeed("server", "not hex"); err == nil {
+		t.Error("VerifyServerSeed() accepted an invalid commitment")
+	}
+}
+
+func TestVerifyProvablyFair(t *testing.T) {
+	serverSeed, err := GenerateServerSeed()
+	if err != nil {
+		t.Fatalf("GenerateServerSeed() error = %v", err)
+	}
+	if len(serverSeed) != ServerSeedSize*2 {
+		t.Errorf("GenerateServerSeed() length = %d, want %d", len(serverSeed), ServerSeedSize*2)
+	}
+	commitment := CommitServerSeed(serverSeed)
+
+	// The house plays the round with the unrevealed server seed.
+	house, err := NewProvablyFair(serverSeed, "lucky", 7).Pick(3, 37)
+	if err != nil {
+		t.Fatalf("Pick() error = %v", err)
+	}
+
+	// The player verifies it once the server seed is revealed.
+	r, err := VerifyProvablyFair(serverSeed, commitment, "lucky", 7)
+	if err != nil {
+		t.Fatalf("VerifyProvablyFair() error = %v", err)
+	}
+	player, err := r.Pick(3, 37)
+	if err != nil {
+		t.Fatalf("Pick() error = %v", err)
+	}
+	for i := range house {
+		if house[i] != player[i] {
+			t.Errorf("Pick()[%d] = %d, want %d", i, player[i], house[i])
+		}
+	}
+
+	if _, err := VerifyProvablyFair(serverSeed, CommitServerSeed("other"), "lucky", 7); err == nil {
+		t.Error("VerifyProvablyFair() accepted a mismatched commitment")
+	}
+}
@@ -2,7 +2,6 @@
 package randomness
 
 import (
-	"crypto/sha512"
 	"encoding/binary"
 	"fmt"
 	"math"
@@ -18,7 +17,7 @@ type Randomness interface {
 
 	// Bits returns a slice of boolean values representing the bits of the
 	// underlying byte slice. A whole byte is consumed at a time even if n is
-	// less than a multiple of 8.
+	// less than a multiple of 8, unless StreamBits is selected.
 	Bits(n int) (BitArray, error)
 
 	// Bytes returns a slice of bytes from the underlying byte slice.
@@ -63,66 +62,179 @@ type Randomness interface {
 	// Selection returns a selection of items from the list of items, and can handle complex item configurations, such as weights and limited supplies.
 	Selection(cfg SelectionConfig) ([]SelectionResult, error)
 
-	// PickDistinct returns n unique random integers in [0, magnitude)
+	// PickDistinct returns n unique random integers in [0, magnitude). From
+	// AlgorithmV4 it takes memory and time proportional to n, so magnitude
+	// can be very large.
 	PickDistinct(n int, magnitude int) ([]int, error)
 
 	// Pick returns n random integers in [0, magnitude) (may include duplicates)
 	Pick(n int, magnitude int) ([]int, error)
+
+	// IntN returns a random integer in [0, n). Like Pick and PickDistinct,
+	// it uses the Sampling selected with WithSampling.
+	IntN(n int) (int, error)
+
+	// Algorithm returns the algorithm version used to derive results.
+	Algorithm() Algorithm
+
+	// Amplifier returns the amplifier used to extend the beta.
+	Amplifier() Amplifier
+
+	// MarshalBinary encodes the cursor state so that it can be restored
+	// with RestoreRandomness.
+	MarshalBinary() ([]byte, error)
+
+	// Fork returns an independent child stream derived from the original
+	// beta and a label, e.g. "deck" or "bonus-wheel".
+	Fork(label string) Randomness
 }
 
 // randomness implements the Randomness interface.
 type randomness struct {
-	data          []byte
-	pos           int
-	originalLen   int
+	beta          []byte
+	data          []byte // Window of the stream starting at offset base
+	base          int
+	pos           int   // Offset of the next byte in the stream
+	bit           uint8 // Bits already consumed of the byte at pos
+	scratch       []byte
 	amplification int
+	algorithm     Algorithm
+	sampling      Sampling
+	stream        Stream
+	amplifier     Amplifier
+	transcript    *Transcript
+	recording     int // Depth of nested calls being recorded
 }
 
 // Randomness is implemented by *randomness.
 var _ Randomness = (*randomness)(nil)
 
-// NewRandomness creates a new Randomness instance from a random string.
-func NewRandomness(β BetaBytes) Randomness {
-	return &randomness{
-		data:        []byte(β),
-		pos:         0,
-		originalLen: len(β),
+// NewRandomness creates a new Randomness instance from a random string using
+// AlgorithmV1.
+func NewRandomness(β BetaBytes, opts ...Option) Randomness {
+	return newRandomness(AlgorithmV1, β, opts...)
+}
+
+func newRandomness(v Algorithm, β BetaBytes, opts ...Option) *randomness {
+	b := &randomness{
+		beta: slices.Clone(β),
+		data: slices.Clone(β),
+		pos:  0,
+	}
+	v.apply(b)
+	for _, opt := range opts {
+		opt(b)
+	}
+	if b.transcript != nil {
+		b.transcript.begin(b)
 	}
+	return b
+}
+
+func (b *randomness) Algorithm() Algorithm {
+	return b.algorithm
+}
+
+func (b *randomness) Amplifier() Amplifier {
+	return b.amplifier
 }
 
 // have tells you how much entropy you have remaining.
 func (b *randomness) have() int {
-	return len(b.data) - b.pos
+	return b.base + len(b.data) - b.pos
 }
 
 // need ensures you have enough entropy to perform your current operation.
-func (b *randomness) need(n int) {
+func (b *randomness) need(n int) error {
+	if b.have() < n {
+		b.compact()
+	}
 	for b.have() < n {
-		b.amplify()
+		if err := b.amplify(); err != nil {
+			return err
+		}
 	}
+	return nil
 }
 
-// get returns a slice of bytes from the underlying byte slice.
+// get returns a slice of bytes from the underlying byte slice. The slice is
+// only valid until the next call to get.
 func (b *randomness) get(n int) ([]byte, error) {
-	b.need(n)
-	tmp := b.data[b.pos : b.pos+n]
+	if b.bit != 0 {
+		return b.getUnaligned(n)
+	}
+	if err := b.need(n); err != nil {
+		return nil, err
+	}
+	tmp := b.data[b.pos-b.base : b.pos-b.base+n]
 	b.pos += n
 	return tmp, nil
 }
 
-// amplify extends the underlying byte slice using SHA-512.
-func (b *randomness) amplify() {
+// window returns the bytes of the stream in [from, to), which must not have
+// been compacted away.
+func (b *randomness) window(from, to int) []byte {
+	return b.data[from-b.base : to-b.base]
+}
+
+// compact drops the consumed bytes from the window so memory stays bounded
+// however much is drawn. Nothing is dropped while a transcript entry is being
+// recorded, as the entry still needs the bytes it consumed.
+func (b *randomness) compact() {
+	if b.recording > 0 || b.pos == b.base {
+		return
+	}
+	n := copy(b.data, b.data[b.pos-b.base:])
+	b.data = b.data[:n]
+	b.base = b.pos
+}
+
+// seek moves the cursor to offset pos of the stream. A position inside the
+// window is reached directly; any other is reached by starting the window
+// at the block that holds it, as the amplifier gives random access to its
+// blocks.
+func (b *randomness) seek(pos int) error {
+	b.bit = 0
+	if pos >= b.base && pos <= b.base+len(b.data) {
+		b.pos = pos
+		return nil
+	}
+	if pos <= len(b.beta) {
+		b.data = append(b.data[:0], b.beta...)
+		b.base = 0
+		b.amplification = 0
+		b.pos = pos
+		return nil
+	}
+	size := len(b.amplifier.Block(b.beta, 1))
+	if size == 0 {
+		return fmt.Errorf("amplifier %q returned an empty block 1", b.amplifier.ID())
+	}
+	blocks := (pos - len(b.beta)) / size
+	b.data = b.data[:0]
+	b.amplification = blocks
+	b.base = len(b.beta) + blocks*size
+	b.pos = pos
+	if b.pos > b.base {
+		return b.amplify()
+	}
+	return nil
+}
+
+// amplify extends the underlying byte slice with the next block of the
+// amplifier. An empty block is an error, as the stream could never grow.
+func (b *randomness) amplify() error {
+	tmp := b.amplifier.Block(b.beta, uint64(b.amplification+1))
+	if len(tmp) == 0 {
+		return fmt.Errorf("amplifier %q returned an empty block %d", b.amplifier.ID(), b.amplification+1)
+	}
 	b.amplification++
-	sha := sha512.New()
-	buf := make([]byte, 8)
-	binary.BigEndian.PutUint64(buf, uint64(b.amplification))
-	sha.Write(b.data[:b.originalLen])
-	sha.Write(buf)
-	tmp := sha.Sum(nil)
 	b.data = append(b.data, tmp...)
+	return nil
 }
 
-func (b *randomness) Probability() (float64, error) {
+func (b *randomness) Probability() (v float64, err error) {
+	defer b.record("Probability", &v)()
 	u64, err := b.Uint64()
 	if err != nil {
 		return 0, err
@@ -131,10 +243,14 @@ func (b *randomness) Probability() (float64, error) {
 	return p, nil
 }
 
-func (b *randomness) Bits(n int) (BitArray, error) {
+func (b *randomness) Bits(n int) (v BitArray, err error) {
+	defer b.record("Bits", &v)()
 	if n < 0 {
 		return nil, fmt.Errorf("cannot generate %d bits: count must be non-negative", n)
 	}
+	if b.stream == StreamBits {
+		return b.getBits(n)
+	}
 
 	var bits []bool
 	for {
@@ -154,14 +270,20 @@ func (b *randomness) Bits(n int) (BitArray, error) {
 	return bits[:n], nil
 }
 
-func (b *randomness) Bytes(n int) ([]byte, error) {
+func (b *randomness) Bytes(n int) (v []byte, err error) {
+	defer b.record("Bytes", &v)()
 	if n < 0 {
 		return nil, fmt.Errorf("cannot generate %d bytes: count must be non-negative", n)
 	}
-	return b.get(n)
+	bytes, err := b.get(n)
+	if err != nil {
+		return nil, err
+	}
+	return slices.Clone(bytes), nil
 }
 
-func (b *randomness) Uint64() (uint64, error) {
+func (b *randomness) Uint64() (v uint64, err error) {
+	defer b.record("Uint64", &v)()
 	bytes, err := b.get(8)
 	if err != nil {
 		return 0, err
@@ -169,7 +291,8 @@ func (b *randomness) Uint64() (uint64, error) {
 	return binary.BigEndian.Uint64(bytes), nil
 }
 
-func (b *randomness) Uint32() (uint32, error) {
+func (b *randomness) Uint32() (v uint32, err error) {
+	defer b.record("Uint32", &v)()
 	bytes, err := b.get(4)
 	if err != nil {
 		return 0, err
@@ -177,7 +300,8 @@ func (b *randomness) Uint32() (uint32, error) {
 	return binary.BigEndian.Uint32(bytes), nil
 }
 
-func (b *randomness) Uint16() (uint16, error) {
+func (b *randomness) Uint16() (v uint16, err error) {
+	defer b.record("Uint16", &v)()
 	bytes, err := b.get(2)
 	if err != nil {
 		return 0, err
@@ -185,7 +309,8 @@ func (b *randomness) Uint16() (uint16, error) {
 	return binary.BigEndian.Uint16(bytes), nil
 }
 
-func (b *randomness) Uint8() (uint8, error) {
+func (b *randomness) Uint8() (v uint8, err error) {
+	defer b.record("Uint8", &v)()
 	bytes, err := b.get(1)
 	if err != nil {
 		return 0, err
@@ -193,7 +318,8 @@ func (b *randomness) Uint8() (uint8, error) {
 	return bytes[0], nil
 }
 
-func (b *randomness) Int64() (int64, error) {
+func (b *randomness) Int64() (v int64, err error) {
+	defer b.record("Int64", &v)()
 	u64, err := b.Uint64()
 	if err != nil {
 		return 0, err
@@ -201,7 +327,8 @@ func (b *randomness) Int64() (int64, error) {
 	return int64(u64), nil
 }
 
-func (b *randomness) Int32() (int32, error) {
+func (b *randomness) Int32() (v int32, err error) {
+	defer b.record("Int32", &v)()
 	u32, err := b.Uint32()
 	if err != nil {
 		return 0, err
@@ -209,7 +336,8 @@ func (b *randomness) Int32() (int32, error) {
 	return int32(u32), nil
 }
 
-func (b *randomness) Int16() (int16, error) {
+func (b *randomness) Int16() (v int16, err error) {
+	defer b.record("Int16", &v)()
 	u16, err := b.Uint16()
 	if err != nil {
 		return 0, err
@@ -217,7 +345,8 @@ func (b *randomness) Int16() (int16, error) {
 	return int16(u16), nil
 }
 
-func (b *randomness) Int8() (int8, error) {
+func (b *randomness) Int8() (v int8, err error) {
+	defer b.record("Int8", &v)()
 	u8, err := b.Uint8()
 	if err != nil {
 		return 0, err
@@ -225,7 +354,8 @@ func (b *randomness) Int8() (int8, error) {
 	return int8(u8), nil
 }
 
-func (b *randomness) Float64() (float64, error) {
+func (b *randomness) Float64() (v float64, err error) {
+	defer b.record("Float64", &v)()
 	u64, err := b.Uint64()
 	if err != nil {
 		return 0, err
@@ -233,7 +363,8 @@ func (b *randomness) Float64() (float64, error) {
 	return math.Float64frombits(u64), nil
 }
 
-func (b *randomness) Float32() (float32, error) {
+func (b *randomness) Float32() (v float32, err error) {
+	defer b.record("Float32", &v)()
 	u32, err := b.Uint32()
 	if err != nil {
 		return 0, err
@@ -241,7 +372,8 @@ func (b *randomness) Float32() (float32, error) {
 	return math.Float32frombits(u32), nil
 }
 
-func (b *randomness) Numbers(count, magnitude int) (Numbers, error) {
+func (b *randomness) Numbers(count, magnitude int) (v Numbers, err error) {
+	defer b.record("Numbers", &v)()
 	if count < 0 {
 		return nil, fmt.Errorf("cannot generate %d numbers: count must be non-negative", count)
 	}
@@ -253,7 +385,11 @@ func (b *randomness) Numbers(count, magnitude int) (Numbers, error) {
 	if err != nil {
 		return nil, err
 	}
-	bits, err := b.Bits(bytesNeeded * 8)
+	bitsNeeded := bytesNeeded * 8
+	if b.stream == StreamBits {
+		bitsNeeded = perNumber * (count + 1)
+	}
+	bits, err := b.Bits(bitsNeeded)
 	if err != nil {
 		return nil, err
 	}
@@ -294,7 +430,8 @@ func U64ToProbability(u uint64) *big.Float {
 }
 
 // PickDistinct returns n unique random integers in [0, magnitude)
-func (b *randomness) PickDistinct(n int, magnitude int) ([]int, error) {
+func (b *randomness) PickDistinct(n int, magnitude int) (v []int, err error) {
+	defer b.record("PickDistinct", &v)()
 	if n < 0 {
 		return nil, fmt.Errorf("cannot generate %d numbers: count must be non-negative", n)
 	}
@@ -305,19 +442,32 @@ func (b *randomness) PickDistinct(n int, magnitude int) ([]int, error) {
 		return nil, fmt.Errorf("cannot pick %d distinct numbers from a range of only %d numbers", n, magnitude)
 	}
 
+	var next func(size int) (int, error)
+	if b.sampling == SamplingRejection {
+		next = func(size int) (int, error) {
+			pos, err := uniform(b, uint64(size))
+			return int(pos), err
+		}
+	} else {
+		numbers, err := b.Numbers(n, magnitude)
+		if err != nil {
+			return nil, err
+		}
+		next = numbers.Read
+	}
+
+	if b.algorithm >= AlgorithmV4 {
+		return pickDistinctSparse(n, magnitude, next)
+	}
+
 	set := make([]int, magnitude)
 	for i := range set {
 		set[i] = int(i)
 	}
 
-	numbers, err := b.Numbers(n, magnitude)
-	if err != nil {
-		return nil, err
-	}
-
 	selected := make([]int, n)
 	for i := range n {
-		pos, err := numbers.Read(len(set))
+		pos, err := next(len(set))
 		if err != nil {
 			return nil, err
 		}
@@ -328,8 +478,37 @@ func (b *randomness) PickDistinct(n int, magnitude int) ([]int, error) {
 	return selected, nil
 }
 
+// pickDistinctSparse picks n distinct integers in [0, magnitude) with a
+// partial Fisher–Yates shuffle of the range. The i-th pick draws a position
+// in [0, magnitude-i) from next, exactly as the dense walk of earlier
+// versions does, and takes the integer at position i plus that offset,
+// swapping the integer at position i into its place. Only the swapped
+// positions are stored, so memory and time scale with n not magnitude.
+func pickDistinctSparse(n, magnitude int, next func(size int) (int, error)) ([]int, error) {
+	swapped := make(map[int]int, n)
+	at := func(pos int) int {
+		if v, ok := swapped[pos]; ok {
+			return v
+		}
+		return pos
+	}
+
+	selected := make([]int, n)
+	for i := range n {
+		pos, err := next(magnitude - i)
+		if err != nil {
+			return nil, err
+		}
+		j := i + pos
+		selected[i] = at(j)
+		swapped[j] = at(i)
+	}
+	return selected, nil
+}
+
 // Pick returns n random integers in [0, magnitude) (may include duplicates)
-func (b *randomness) Pick(n int, magnitude int) ([]int, error) {
+func (b *randomness) Pick(n int, magnitude int) (v []int, err error) {
+	defer b.record("Pick", &v)()
 	if n < 0 {
 		return nil, fmt.Errorf("cannot generate %d numbers: count must be non-negative", n)
 	}
@@ -337,6 +516,18 @@ func (b *randomness) Pick(n int, magnitude int) ([]int, error) {
 		return nil, fmt.Errorf("cannot generate numbers in range [0, %d): magnitude must be positive", magnitude)
 	}
 
+	if b.sampling == SamplingRejection {
+		result := make([]int, n)
+		for i := range n {
+			u, err := uniform(b, uint64(magnitude))
+			if err != nil {
+				return nil, err
+			}
+			result[i] = int(u)
+		}
+		return result, nil
+	}
+
 	numbers, err := b.Numbers(n, magnitude)
 	if err != nil {
 		return nil, err
@@ -351,3 +542,21 @@ func (b *randomness) Pick(n int, magnitude int) ([]int, error) {
 	}
 	return result, nil
 }
+
+// IntN returns a random integer in [0, n). With SamplingAccumulator it is
+// equivalent to the first value of Pick(1, n).
+func (b *randomness) IntN(n int) (v int, err error) {
+	defer b.record("IntN", &v)()
+	if n <= 0 {
+		return 0, fmt.Errorf("cannot generate a number in range [0, %d): n must be positive", n)
+	}
+	if b.sampling == SamplingRejection {
+		u, err := uniform(b, uint64(n))
+		return int(u), err
+	}
+	nums, err := b.Pick(1, n)
+	if err != nil {
+		return 0, err
+	}
+	return nums[0], nil
+}
@@ -122,3 +122,37 @@ func TestHashValues(t *testing.T) {
 		})
 	}
 }
+
+func TestStreamMemoryIsBounded(t *testing.T) {
+	r := newRandomness(AlgorithmV1, BetaBytes("test"))
+	for range 100000 {
+		if _, err := r.Uint64(); err != nil {
+			t.Fatalf("Uint64() error = %v", err)
+		}
+	}
+	if cap(r.data) > 256 {
+		t.Errorf("window capacity = %d after 100000 draws, want at most 256", cap(r.data))
+	}
+
+	// The output is identical to the fully expanded stream.
+	expanded := []byte("test")
+	for i := uint64(1); len(expanded) < 800016; i++ {
+		expanded = append(expanded, AmplifierSHA512.Block([]byte("test"), i)...)
+	}
+	got, _ := r.Bytes(16)
+	if !bytes.Equal(got, expanded[800000:800016]) {
+		t.Errorf("Bytes() at offset 800000 = %x, want %x", got, expanded[800000:800016])
+	}
+}
+
+func BenchmarkUint64Stream(b *testing.B) {
+	const draws = 10_000_000
+	b.ReportAllocs()
+	for range b.N {
+		r := newRandomness(AlgorithmV1, BetaBytes("test"))
+		for range draws {
+			r.Uint64()
+		}
+		b.ReportMetric(float64(cap(r.data)), "window-bytes")
+	}
+}
@@ -0,0 +1,94 @@
+package randomness
+
+import (
+	"fmt"
+	"math/big"
+	"math/bits"
+)
+
+// Sampling selects the algorithm used to derive bounded integers.
+type Sampling int
+
+const (
+	// SamplingAccumulator derives bounded integers from a Numbers
+	// accumulator, reducing each value modulo the magnitude. It is kept so
+	// historical rounds can be reproduced, but any magnitude that is not a
+	// power of two yields a slightly biased distribution.
+	SamplingAccumulator Sampling = iota
+
+	// SamplingRejection derives each bounded integer from whole Uint64
+	// reads using Lemire's multiply-shift method. A read is rejected and
+	// another is taken whenever it would fall in the biased region, so the
+	// result is exactly uniform and the number of reads is variable.
+	SamplingRejection
+)
+
+func (s Sampling) String() string {
+	switch s {
+	case SamplingAccumulator:
+		return "accumulator"
+	case SamplingRejection:
+		return "rejection"
+	default:
+		return fmt.Sprintf("Sampling(%d)", int(s))
+	}
+}
+
+// ParseSampling parses a sampling in the form returned by Sampling.String,
+// e.g. "rejection".
+func ParseSampling(s string) (Sampling, error) {
+	for _, sampling := range []Sampling{SamplingAccumulator, SamplingRejection} {
+		if sampling.String() == s {
+			return sampling, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown sampling %q", s)
+}
+
+// uniform returns a uniformly distributed integer in [0, n) using Lemire's
+// multiply-shift method with rejection.
+//
+// Each attempt reads one Uint64 x and computes the 128-bit product x*n. The
+// high 64 bits are the candidate; the attempt is rejected when the low 64
+// bits are below 2^64 mod n, in which case another Uint64 is read.
+func uniform(r Randomness, n uint64) (uint64, error) {
+	if n == 0 {
+		return 0, fmt.Errorf("cannot generate a number in range [0, 0)")
+	}
+	threshold := -n % n
+	for {
+		x, err := r.Uint64()
+		if err != nil {
+			return 0, err
+		}
+		hi, lo := bits.Mul64(x, n)
+		if lo >= threshold {
+			return hi, nil
+		}
+	}
+}
+
+// uniformBig returns a uniformly distributed integer in [0, n) by rejection
+// sampling. Each attempt reads the fewest whole bytes holding the bit length
+// k of n-1 with Bytes, clears all but the low k bits of the big-endian value
+// and rejects it if it is not below n.
+func uniformBig(r Randomness, n *big.Int) (*big.Int, error) {
+	if n.Sign() <= 0 {
+		return nil, fmt.Errorf("cannot generate a number in range [0, %s)", n)
+	}
+	k := new(big.Int).Sub(n, big.NewInt(1)).BitLen()
+	mask := byte(0xff >> (7 - (k+7)%8))
+	for {
+		buf, err := r.Bytes((k + 7) / 8)
+		if err != nil {
+			return nil, err
+		}
+		if len(buf) > 0 {
+			buf[0] &= mask
+		}
+		u := new(big.Int).SetBytes(buf)
+		if u.Cmp(n) < 0 {
+			return u, nil
+		}
+	}
+}
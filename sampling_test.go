@@ -0,0 +1,83 @@
+package randomness
+
+import (
+	"math"
+	"testing"
+)
+
+func TestUniformRejection(t *testing.T) {
+	// 2^64 mod 3 = 1, so a zero read is rejected and the next one is used.
+	r := NewRandomness(BetaValues(uint64(0), uint64(math.MaxUint64)), WithSampling(SamplingRejection))
+	n, err := r.IntN(3)
+	if err != nil {
+		t.Fatalf("IntN() error = %v", err)
+	}
+	if n != 2 {
+		t.Errorf("IntN(3) = %d, want 2", n)
+	}
+}
+
+func TestIntN(t *testing.T) {
+	for _, sampling := range []Sampling{SamplingAccumulator, SamplingRejection} {
+		t.Run(sampling.String(), func(t *testing.T) {
+			r := NewRandomness(BetaValues(GenerateTestRandomValue()), WithSampling(sampling))
+			for range 1000 {
+				n, err := r.IntN(10)
+				if err != nil {
+					t.Fatalf("IntN() error = %v", err)
+				}
+				if n < 0 || n >= 10 {
+					t.Fatalf("IntN(10) = %d, out of range", n)
+				}
+			}
+			if _, err := r.IntN(0); err == nil {
+				t.Error("IntN(0) did not return an error")
+			}
+		})
+	}
+
+	// The accumulator mode matches Pick so historical rounds reproduce.
+	a, _ := NewRandomness(BetaBytes("test")).IntN(37)
+	b, _ := NewRandomness(BetaBytes("test")).Pick(1, 37)
+	if a != b[0] {
+		t.Errorf("IntN(37) = %d, want %d", a, b[0])
+	}
+}
+
+func TestRejectionDistribution(t *testing.T) {
+	// A magnitude just above a power of two is where modulo bias is worst.
+	magnitude := 3
+	iterations := 90000
+	counts := make([]int, magnitude)
+
+	r := NewRandomness(BetaValues(GenerateTestRandomValue()), WithSampling(SamplingRejection))
+	picks, err := r.Pick(iterations, magnitude)
+	if err != nil {
+		t.Fatalf("Pick() error = %v", err)
+	}
+	for _, p := range picks {
+		counts[p]++
+	}
+
+	expected := float64(iterations) / float64(magnitude)
+	for value, count := range counts {
+		if math.Abs(float64(count)-expected)/expected > 0.05 {
+			t.Errorf("Value %d: count = %d, expected ≈ %.0f", value, count, expected)
+		}
+	}
+}
+
+func TestRejectionPickDistinct(t *testing.T) {
+	r := NewRandomness(BetaValues(GenerateTestRandomValue()), WithSampling(SamplingRejection))
+	picked, err := r.PickDistinct(10, 10)
+	if err != nil {
+		t.Fatalf("PickDistinct() error = %v", err)
+	}
+	seen := make(map[int]bool)
+	for _, p := range picked {
+		if p < 0 || p >= 10 || seen[p] {
+			t.Errorf("PickDistinct() returned invalid or duplicate value %d", p)
+		}
+		seen[p] = true
+	}
+}
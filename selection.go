@@ -1,21 +1,53 @@
 package randomness
 
 import (
+	"encoding/json"
 	"fmt"
+	"slices"
 )
 
 type SelectionResult interface {
 	Item
 	Instance() int
 	Get() Item
-	Fraction() float64 // Returns the fractional position within the instance (0-1)
+	Fraction() float64        // Returns the fractional position within the instance (0-1)
+	Explanation() Explanation // Returns the odds that applied when it was selected
+}
+
+// Explanation describes the odds that applied when a result was selected, so
+// that a roll can be shown to a player: "you rolled 0.7312, which fell in
+// (0.70, 0.75] → Sword".
+type Explanation struct {
+	// Probability is the chance of selecting the item at that step: its
+	// available weight divided by the total available weight.
+	Probability float64 `json:"probability"`
+
+	// ItemWeight is the weight of the instances of the item that could
+	// still be selected, and TotalWeight that of all items.
+	ItemWeight  float64 `json:"itemWeight"`
+	TotalWeight float64 `json:"totalWeight"`
+
+	// Roll is the value drawn. For float64 selections it is the
+	// Probability read, in (0, 1], and the item is selected when it falls
+	// in the interval (Low, High]. For exact selections it is the uniform
+	// integer drawn divided by the total integer weight, in [0, 1), and the
+	// interval is [Low, High).
+	Roll float64 `json:"roll"`
+
+	// Low and High bound the interval of the item among the cumulative
+	// weights of the items, in item order, scaled to [0, 1]. In a
+	// constrained selection only the items eligible at that step count.
+	Low  float64 `json:"low"`
+	High float64 `json:"high"`
 }
 
 // SelectionResult represents a single selected item with its instance number
 type selectionResult struct {
-	Item             // The selected item
-	instance int     // The instance number of the selected item (for finite supplies)
-	fraction float64 // The fractional position within the instance (0-1)
+	Item                    // The selected item
+	instance    int         // The instance number of the selected item (for finite supplies)
+	fraction    float64     // The fractional position within the instance (0-1)
+	index       int         // The index of the item state it was selected from
+	explanation Explanation // The odds when it was selected
 }
 
 func (s *selectionResult) Instance() int {
@@ -30,6 +62,27 @@ func (s *selectionResult) Fraction() float64 {
 	return s.fraction
 }
 
+func (s *selectionResult) Explanation() Explanation {
+	return s.explanation
+}
+
+// MarshalJSON encodes the result with its item, instance, fraction and
+// explanation, so a selection recorded in a Transcript can be checked:
+//
+//	{"item": {...}, "instance": 2, "fraction": 0.37, "explanation": {...}}
+func (s *selectionResult) MarshalJSON() ([]byte, error) {
+	item, err := encodeItem(s.Item)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(struct {
+		Item        json.RawMessage `json:"item"`
+		Instance    int             `json:"instance"`
+		Fraction    float64         `json:"fraction"`
+		Explanation Explanation     `json:"explanation"`
+	}{item, s.instance, s.fraction, s.explanation})
+}
+
 // SelectionConfig represents the configuration for a selection operation.
 // It contains the items to select from, the number of items to select,
 // and tracks the state of each item's instances.
@@ -37,6 +90,26 @@ type SelectionConfig struct {
 	Items      []Item
 	Count      int
 	ItemStates []itemState
+
+	// Exact selects with exact integer arithmetic rather than float64
+	// probabilities: weights are taken as exact rationals (see
+	// ExactWeighter) and each selection is a uniform integer over the total
+	// weight, so the odds are mathematically exact. Each selection reads
+	// the fewest whole bytes holding the bit length of the total weight
+	// minus one with Bytes, rejecting values that are not below it.
+	Exact bool
+
+	// Constraints bound the number of selected instances of items with
+	// each tag (see Tagger), and UniqueIDs selects at most one instance of
+	// each item ID (see Identifier). Items without tags or an ID are
+	// unconstrained. ValidateSelectionConfig rejects constraints that no
+	// selection can meet. Each selection is then made from the items of
+	// which one more instance can be taken while the rest of the selection
+	// can still meet every constraint, with the remaining items' weights
+	// unchanged, so a constrained selection consumes the stream exactly as
+	// the same selections would over those items alone.
+	Constraints []TagConstraint
+	UniqueIDs   bool
 }
 
 // itemState tracks the state of an item's instances during selection.
@@ -47,6 +120,16 @@ type itemState struct {
 	RemainingSupply int
 	UsedInstances   map[int]bool
 	IsConsumed      bool // Whether this item's supply is consumed (finite) or not (infinite)
+
+	unused  *fenwick[int]   // Unused instances, see selectInstancesTree
+	journal *[]usedInstance // Instances used by the current Selector draw, if any
+}
+
+// usedInstance is an instance marked as used, recorded so a failed draw of a
+// Selector can return it to the pool.
+type usedInstance struct {
+	used     map[int]bool
+	instance int
 }
 
 // Reset resets the selection state, allowing all instances to be selected again.
@@ -55,6 +138,7 @@ func (c *SelectionConfig) Reset() {
 	for i := range c.ItemStates {
 		c.ItemStates[i].RemainingSupply = c.ItemStates[i].OriginalSupply
 		c.ItemStates[i].UsedInstances = make(map[int]bool)
+		c.ItemStates[i].unused = nil
 	}
 }
 
@@ -68,40 +152,67 @@ func (c *SelectionConfig) Reset() {
 // For example, if there are 3 finite apples and 3 infinite oranges (both weight 1.0), the first selection
 // has a 3/6 chance of being an orange or apple. If an apple is selected, the next selection has a 3/5
 // chance of being an orange since there are 2 apples and 3 infinite oranges remaining.
-func (r *randomness) Selection(cfg SelectionConfig) ([]SelectionResult, error) {
+// From AlgorithmV3 each selection takes O(log n) time in the total supply.
+func (r *randomness) Selection(cfg SelectionConfig) (v []SelectionResult, err error) {
+	defer r.record("Selection", &v)()
 	// Validate the configuration
 	if err := ValidateSelectionConfig(cfg); err != nil {
 		return nil, err
 	}
 
-	// Initialize results slice
-	results := make([]SelectionResult, 0, cfg.Count)
-
 	// Initialize item states if not already done
 	if cfg.ItemStates == nil {
-		cfg.ItemStates = make([]itemState, len(cfg.Items))
-		for i, item := range cfg.Items {
-			supply := item.Supply()
-			originalSupply := supply
-			if supply < 0 {
-				originalSupply = -supply // Convert negative to positive for tracking
-			}
-			cfg.ItemStates[i] = itemState{
-				Item:            item,
-				OriginalSupply:  originalSupply,
-				RemainingSupply: originalSupply,
-				UsedInstances:   make(map[int]bool),
-				IsConsumed:      supply >= 0,
-			}
+		cfg.ItemStates = newItemStates(cfg.Items)
+	}
+
+	engine := selectInstances
+	if cfg.Exact {
+		engine = selectInstancesExact
+	} else if r.algorithm >= AlgorithmV3 {
+		engine = selectInstancesTree
+	}
+	if cfg.constrained() {
+		c, err := newConstraints(cfg, cfg.ItemStates)
+		if err != nil {
+			return nil, err
 		}
+		return selectConstrained(r, c, cfg.Count, engine)
 	}
+	return engine(r, cfg.ItemStates, cfg.Count)
+}
+
+// newItemStates returns the initial state of every item.
+func newItemStates(items []Item) []itemState {
+	states := make([]itemState, len(items))
+	for i, item := range items {
+		supply := item.Supply()
+		originalSupply := supply
+		if supply < 0 {
+			originalSupply = -supply // Convert negative to positive for tracking
+		}
+		states[i] = itemState{
+			Item:            item,
+			OriginalSupply:  originalSupply,
+			RemainingSupply: originalSupply,
+			UsedInstances:   make(map[int]bool),
+			IsConsumed:      supply >= 0,
+		}
+	}
+	return states
+}
+
+// selectInstances makes count weighted selections from the item states,
+// marking the selected finite instances as used.
+func selectInstances(r Randomness, states []itemState, count int) ([]SelectionResult, error) {
+	// Initialize results slice
+	results := make([]SelectionResult, 0, count)
 
 	// Perform the requested number of selections
-	for range cfg.Count {
+	for range count {
 		// Calculate total weight of all available instances
 		totalWeight := 0.0
-		for i := range cfg.ItemStates {
-			state := &cfg.ItemStates[i]
+		for i := range states {
+			state := &states[i]
 			if !state.IsConsumed {
 				// For infinite supply items, their weight is multiplied by their supply magnitude
 				totalWeight += state.Item.Weight() * float64(state.OriginalSupply)
@@ -129,17 +240,21 @@ func (r *randomness) Selection(cfg SelectionConfig) ([]SelectionResult, error) {
 		// Select an instance using normalized weights
 		accumulatedProb := 0.0
 		var selectedState *itemState
+		var selectedIndex int
 		var selectedInstance int
 		var fractionalPos float64
+		var itemLow float64
 
-		for i := range cfg.ItemStates {
-			state := &cfg.ItemStates[i]
+		for i := range states {
+			state := &states[i]
+			itemLow = accumulatedProb
 			if !state.IsConsumed {
 				// For infinite supply items, their weight is multiplied by their supply magnitude
 				weight := state.Item.Weight() * float64(state.OriginalSupply)
 				accumulatedProb += weight / totalWeight
 				if prob <= accumulatedProb {
 					selectedState = state
+					selectedIndex = i
 					// For infinite supply items, we can reuse any instance number
 					selectedInstance = 1
 					// Calculate fractional position
@@ -159,6 +274,7 @@ func (r *randomness) Selection(cfg SelectionConfig) ([]SelectionResult, error) {
 						accumulatedProb += instanceWeight
 						if prob <= accumulatedProb {
 							selectedState = state
+							selectedIndex = i
 							selectedInstance = instance
 							// Calculate fractional position
 							fractionalPos = (prob - (accumulatedProb - instanceWeight)) / instanceWeight
@@ -172,17 +288,49 @@ func (r *randomness) Selection(cfg SelectionConfig) ([]SelectionResult, error) {
 			}
 		}
 
+		// Rounding can leave the accumulated probability just below a roll of
+		// 1; take the last instance that can be selected, as
+		// selectInstancesTree takes the nearest available item.
+		if selectedState == nil {
+			selectedIndex = nearestAvailable(availableWeights(states), len(states))
+			selectedState = &states[selectedIndex]
+			selectedInstance = 1
+			if selectedState.IsConsumed {
+				for instance := selectedState.OriginalSupply; instance >= 1; instance-- {
+					if !selectedState.UsedInstances[instance] {
+						selectedInstance = instance
+						break
+					}
+				}
+			}
+			fractionalPos = 1
+			itemLow = accumulatedProb - selectedState.available()/totalWeight
+		}
+
+		// Explain the odds of the item before its instance is marked as used
+		itemWeight := selectedState.available()
+		explanation := Explanation{
+			Probability: itemWeight / totalWeight,
+			ItemWeight:  itemWeight,
+			TotalWeight: totalWeight,
+			Roll:        prob,
+			Low:         itemLow,
+			High:        itemLow + itemWeight/totalWeight,
+		}
+
 		// Mark the selected instance as used (only for finite supply items)
 		if selectedState.IsConsumed {
-			selectedState.UsedInstances[selectedInstance] = true
-			selectedState.RemainingSupply--
+			selectedState.use(selectedInstance)
+			selectedState.unused = nil
 		}
 
 		// Add the selected instance to the results
 		results = append(results, &selectionResult{
-			Item:     selectedState.Item,
-			instance: selectedInstance,
-			fraction: fractionalPos,
+			Item:        selectedState.Item,
+			instance:    selectedInstance,
+			fraction:    fractionalPos,
+			index:       selectedIndex,
+			explanation: explanation,
 		})
 	}
 
@@ -226,5 +374,187 @@ func ValidateSelectionConfig(cfg SelectionConfig) error {
 		}
 	}
 
+	if cfg.constrained() {
+		states := cfg.ItemStates
+		if states == nil {
+			states = newItemStates(cfg.Items)
+		}
+		c, err := newConstraints(cfg, states)
+		if err != nil {
+			return err
+		}
+		ok, err := c.feasible(cfg.Count)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("selection constraints cannot be satisfied")
+		}
+	}
+
 	return nil
 }
+
+// selectInstancesTree makes count weighted selections from the item states
+// like selectInstances, but in O(log n) per selection rather than walking
+// every instance. The items are walked in the same order with the same
+// weights, so the results match selectInstances except where floating point
+// rounding of the accumulated weights differs.
+//
+// The drawn probability is scaled by the total weight and located in a
+// Fenwick tree of the available weight of each item. Within a finite item the
+// remainder selects the n-th unused instance, located in a Fenwick tree of
+// unused instances that is only built once an instance of the item is used
+// and is kept with the item state for later selections.
+func selectInstancesTree(r Randomness, states []itemState, count int) ([]SelectionResult, error) {
+	weights := availableWeights(states)
+	items := newFenwick(weights)
+
+	results := make([]SelectionResult, 0, count)
+	for range count {
+		if !slices.ContainsFunc(weights, func(w float64) bool { return w > 0 }) {
+			return nil, fmt.Errorf("no items remaining with non-zero supply")
+		}
+		totalWeight := items.prefix(items.len())
+
+		prob, err := r.Probability()
+		if err != nil {
+			return nil, err
+		}
+
+		i, before := items.search(prob * totalWeight)
+		if i == len(states) || weights[i] == 0 {
+			// Rounding residue in the tree put the target on an empty
+			// item or past the end; take the nearest available item.
+			i = nearestAvailable(weights, i)
+			before = items.prefix(i)
+		}
+		state := &states[i]
+		offset := max(prob*totalWeight-before, 0)
+		explanation := Explanation{
+			Probability: weights[i] / totalWeight,
+			ItemWeight:  weights[i],
+			TotalWeight: totalWeight,
+			Roll:        prob,
+			Low:         before / totalWeight,
+			High:        (before + weights[i]) / totalWeight,
+		}
+
+		if !state.IsConsumed {
+			results = append(results, &selectionResult{
+				Item:        state.Item,
+				instance:    1,
+				fraction:    min(offset/weights[i], 1),
+				index:       i,
+				explanation: explanation,
+			})
+			continue
+		}
+
+		weight := state.Item.Weight()
+		rank := min(int(offset/weight), state.RemainingSupply-1)
+		fraction := min(offset/weight-float64(rank), 1)
+
+		instance := state.unusedInstance(rank)
+
+		state.use(instance)
+		previous := weights[i]
+		weights[i] = state.available()
+		items.add(i, weights[i]-previous)
+
+		results = append(results, &selectionResult{
+			Item:        state.Item,
+			instance:    instance,
+			fraction:    fraction,
+			index:       i,
+			explanation: explanation,
+		})
+	}
+
+	return results, nil
+}
+
+// nearestAvailable returns the first item from i onwards with a non-zero
+// weight, or failing that the last one before i.
+func nearestAvailable(weights []float64, i int) int {
+	for j := i; j < len(weights); j++ {
+		if weights[j] > 0 {
+			return j
+		}
+	}
+	for j := min(i, len(weights)) - 1; j >= 0; j-- {
+		if weights[j] > 0 {
+			return j
+		}
+	}
+	return -1
+}
+
+// availableWeights returns the available weight of every item.
+func availableWeights(states []itemState) []float64 {
+	weights := make([]float64, len(states))
+	for i := range states {
+		weights[i] = states[i].available()
+	}
+	return weights
+}
+
+// use marks an instance of the item as used.
+func (s *itemState) use(instance int) {
+	s.UsedInstances[instance] = true
+	s.RemainingSupply--
+	if s.journal != nil {
+		*s.journal = append(*s.journal, usedInstance{s.UsedInstances, instance})
+	}
+}
+
+// available returns the total weight of the instances of the item that can
+// still be selected.
+func (s *itemState) available() float64 {
+	if !s.IsConsumed {
+		// For infinite supply items, their weight is multiplied by their supply magnitude
+		return s.Item.Weight() * float64(s.OriginalSupply)
+	}
+	return s.Item.Weight() * float64(s.RemainingSupply)
+}
+
+// unusedInstance returns the instance number of the unused instance with the
+// given zero-based rank and removes it from the tree of unused instances.
+// While only a few instances are used it counts past them directly rather
+// than building the tree.
+func (s *itemState) unusedInstance(rank int) int {
+	if s.unused == nil && len(s.UsedInstances) <= 64 {
+		used := make([]int, 0, len(s.UsedInstances))
+		for instance, ok := range s.UsedInstances {
+			if ok {
+				used = append(used, instance)
+			}
+		}
+		slices.Sort(used)
+		instance := rank + 1
+		for _, u := range used {
+			if u <= instance {
+				instance++
+			}
+		}
+		return instance
+	}
+
+	if s.unused == nil {
+		s.unused = s.unusedTree()
+	}
+	index, _ := s.unused.search(rank + 1)
+	s.unused.add(index, -1)
+	return index + 1
+}
+
+// unusedTree returns a Fenwick tree with a one for every unused instance.
+func (s *itemState) unusedTree() *fenwick[int] {
+	flags := make([]int, s.OriginalSupply)
+	for instance := range flags {
+		if !s.UsedInstances[instance+1] {
+			flags[instance] = 1
+		}
+	}
+	return newFenwick(flags)
+}
@@ -276,3 +276,143 @@ func TestResetSelection(t *testing.T) {
 		}
 	}
 }
+
+func TestSelectionTreeMatchesWalk(t *testing.T) {
+	// Weights that sum exactly in floating point never round differently.
+	items := []Item{
+		&testItem{value: 1, weight: 1.0, supply: 5},
+		&testItem{value: 2, weight: 0.5, supply: -3},
+		&testItem{value: 3, weight: 2.0, supply: 0},
+		&testItem{value: 4, weight: 0.25, supply: 8},
+		&testItem{value: 5, weight: 4.0, supply: 2},
+	}
+
+	for i := range 1000 {
+		beta := HashValues(uint64(i))
+		walk, _ := NewRandomnessV(AlgorithmV2, beta)
+		tree, _ := NewRandomnessV(AlgorithmV3, beta)
+
+		want, err := walk.Selection(SelectionConfig{Items: items, Count: 12})
+		if err != nil {
+			t.Fatalf("Selection() error = %v", err)
+		}
+		got, err := tree.Selection(SelectionConfig{Items: items, Count: 12})
+		if err != nil {
+			t.Fatalf("Selection() error = %v", err)
+		}
+		for j := range want {
+			if got[j].Get() != want[j].Get() || got[j].Instance() != want[j].Instance() ||
+				math.Abs(got[j].Fraction()-want[j].Fraction()) > 1e-9 {
+				t.Fatalf("seed %d selection %d = %d/%d (%v), want %d/%d (%v)", i, j,
+					got[j].Get().(*testItem).value, got[j].Instance(), got[j].Fraction(),
+					want[j].Get().(*testItem).value, want[j].Instance(), want[j].Fraction())
+			}
+		}
+	}
+}
+
+func TestSelectionExplanation(t *testing.T) {
+	engines := []struct {
+		name      string
+		algorithm Algorithm
+		exact     bool
+	}{
+		{"walk", AlgorithmV2, false},
+		{"tree", AlgorithmV3, false},
+		{"exact", AlgorithmV3, true},
+	}
+	for _, engine := range engines {
+		t.Run(engine.name, func(t *testing.T) {
+			r, _ := NewRandomnessV(engine.algorithm, BetaBytes("test"))
+			results, err := r.Selection(SelectionConfig{Items: goldenItems(), Count: 4, Exact: engine.exact})
+			if err != nil {
+				t.Fatalf("Selection() error = %v", err)
+			}
+
+			// a is 1 x 3, b is 2.5 x 2 and c is 0.5 x 1 before any draw.
+			total := 8.5
+			for d, result := range results {
+				e := result.Explanation()
+				if math.Abs(e.TotalWeight-total) > 1e-9 {
+					t.Errorf("draw %d TotalWeight = %v, want %v", d, e.TotalWeight, total)
+				}
+				if math.Abs(e.Probability-e.ItemWeight/e.TotalWeight) > 1e-9 || math.Abs(e.High-e.Low-e.Probability) > 1e-9 {
+					t.Errorf("draw %d explanation %+v is inconsistent", d, e)
+				}
+				inside := e.Low < e.Roll && e.Roll <= e.High
+				if engine.exact {
+					inside = e.Low <= e.Roll && e.Roll < e.High
+				}
+				if !inside {
+					t.Errorf("draw %d roll %v is outside [%v, %v]", d, e.Roll, e.Low, e.High)
+				}
+				if result.Get().Supply() >= 0 {
+					total -= result.Get().Weight()
+				}
+			}
+		})
+	}
+}
+
+func TestSelectionRollOfOne(t *testing.T) {
+	// A roll of exactly 1 falls just past 0.1 + 0.1 + 0.6 accumulated in
+	// float64, so the walk must fall back to the last available item.
+	items := []Item{
+		NewGenericItem("a", 0.1, -1),
+		NewGenericItem("b", 0.1, -1),
+		NewGenericItem("c", 0.6, -1),
+	}
+	for v := AlgorithmV1; v <= AlgorithmLatest; v++ {
+		r, _ := NewRandomnessV(v, BetaValues(uint64(math.MaxUint64)))
+		results, err := r.Selection(SelectionConfig{Items: items, Count: 1})
+		if err != nil {
+			t.Fatalf("%s: Selection() error = %v", v, err)
+		}
+		if got := results[0].Any(); got != "c" {
+			t.Errorf("%s: Selection() = %v, want c", v, got)
+		}
+	}
+
+	// The same fall back picks the last unused instance of a finite item.
+	finite := []Item{NewGenericItem("a", 0.1, -1), NewGenericItem("b", 0.1, -1), NewGenericItem("c", 0.3, 2)}
+	results, err := NewRandomness(BetaValues(uint64(math.MaxUint64))).Selection(SelectionConfig{Items: finite, Count: 1})
+	if err != nil {
+		t.Fatalf("Selection() error = %v", err)
+	}
+	if results[0].Any() != "c" || results[0].Instance() != 2 {
+		t.Errorf("Selection() = %v/%d, want c/2", results[0].Any(), results[0].Instance())
+	}
+}
+
+func benchmarkSelection(b *testing.B, algorithm Algorithm, tickets int) {
+	items := []Item{
+		&testItem{value: 1, weight: 1.0, supply: tickets / 2},
+		&testItem{value: 2, weight: 2.0, supply: tickets / 2},
+	}
+	r, _ := NewRandomnessV(algorithm, BetaBytes("test"))
+	b.ResetTimer()
+	for range b.N {
+		if _, err := r.Selection(SelectionConfig{Items: items, Count: 10}); err != nil {
+			b.Fatalf("Selection() error = %v", err)
+		}
+	}
+}
+
+func BenchmarkSelectionWalk1M(b *testing.B) { benchmarkSelection(b, AlgorithmV2, 1_000_000) }
+func BenchmarkSelectionTree1M(b *testing.B) { benchmarkSelection(b, AlgorithmV3, 1_000_000) }
+func BenchmarkSelectionTree2M(b *testing.B) { benchmarkSelection(b, AlgorithmV3, 2_000_000) }
+
+func BenchmarkSelectorTree2M(b *testing.B) {
+	// A raffle drawing one ticket at a time from a depleting pool.
+	s, _ := NewSelector([]Item{&testItem{value: 1, weight: 1.0, supply: 2_000_000}})
+	r, _ := NewRandomnessV(AlgorithmV3, BetaBytes("test"))
+	b.ResetTimer()
+	for range b.N {
+		if n, _ := s.Remaining(0); n == 0 {
+			s.Reset()
+		}
+		if _, err := s.Draw(r, 1); err != nil {
+			b.Fatalf("Draw() error = %v", err)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package randomness
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Selector is a stateful pool of items that remembers which instances of
+// finite supply items have been drawn across successive calls to Draw, e.g.
+// a loot pool that depletes over a season. Infinite supply items are never
+// depleted. A Selector is not safe for concurrent use.
+type Selector struct {
+	items  []Item
+	states []itemState
+}
+
+// NewSelector creates a Selector with the full supply of every item.
+func NewSelector(items []Item) (*Selector, error) {
+	if err := ValidateSelectionConfig(SelectionConfig{Items: items, Count: 1}); err != nil {
+		return nil, err
+	}
+	return &Selector{
+		items:  slices.Clone(items),
+		states: newItemStates(items),
+	}, nil
+}
+
+// Draw selects n instances from the remaining supply using r, exactly as
+// Randomness.Selection would with the current state, and marks the selected
+// finite instances as used. If the selection fails, the pool is unchanged.
+func (s *Selector) Draw(r Randomness, n int) ([]SelectionResult, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("count must be positive")
+	}
+	if remaining, ok := s.finite(); ok && remaining < n {
+		return nil, fmt.Errorf("remaining supply (%d) is less than requested count (%d)", remaining, n)
+	}
+	var journal []usedInstance
+	supplies := make([]int, len(s.states))
+	for i := range s.states {
+		supplies[i] = s.states[i].RemainingSupply
+		s.states[i].journal = &journal
+	}
+	results, err := r.Selection(SelectionConfig{Items: s.items, Count: n, ItemStates: s.states})
+	for i := range s.states {
+		s.states[i].journal = nil
+	}
+	if err != nil {
+		// Return the instances used before the failure to the pool. The
+		// trees of unused instances are rebuilt when next needed.
+		for _, u := range journal {
+			delete(u.used, u.instance)
+		}
+		for i, supply := range supplies {
+			s.states[i].RemainingSupply = supply
+			s.states[i].unused = nil
+		}
+		return nil, err
+	}
+	return results, nil
+}
+
+// state returns the state of item i.
+func (s *Selector) state(i int) (*itemState, error) {
+	if i < 0 || i >= len(s.states) {
+		return nil, fmt.Errorf("item index %d out of range [0, %d)", i, len(s.states))
+	}
+	return &s.states[i], nil
+}
+
+// finite returns the total remaining supply and true if every item has a
+// finite supply.
+func (s *Selector) finite() (int, bool) {
+	total := 0
+	for _, state := range s.states {
+		if !state.IsConsumed {
+			return 0, false
+		}
+		total += state.RemainingSupply
+	}
+	return total, true
+}
+
+// Items returns the items of the pool.
+func (s *Selector) Items() []Item {
+	return slices.Clone(s.items)
+}
+
+// Remaining returns the remaining supply of item i. Like Item.Supply, it is
+// negative for infinite supply items, which are never depleted.
+func (s *Selector) Remaining(i int) (int, error) {
+	state, err := s.state(i)
+	if err != nil {
+		return 0, err
+	}
+	if !state.IsConsumed {
+		return -state.OriginalSupply, nil
+	}
+	return state.RemainingSupply, nil
+}
+
+// Used returns the instance numbers of item i that have been drawn, in
+// ascending order.
+func (s *Selector) Used(i int) ([]int, error) {
+	state, err := s.state(i)
+	if err != nil {
+		return nil, err
+	}
+	var used []int
+	for instance, ok := range state.UsedInstances {
+		if ok {
+			used = append(used, instance)
+		}
+	}
+	slices.Sort(used)
+	return used, nil
+}
+
+// Reset returns every drawn instance to the pool.
+func (s *Selector) Reset() {
+	for i := range s.states {
+		s.states[i].RemainingSupply = s.states[i].OriginalSupply
+		s.states[i].UsedInstances = make(map[int]bool)
+		s.states[i].unused = nil
+	}
+}
+
+// Refill returns up to n drawn instances of item i to the pool, lowest
+// instance numbers first, and returns how many were returned.
+func (s *Selector) Refill(i, n int) (int, error) {
+	if n <= 0 {
+		return 0, fmt.Errorf("count must be positive")
+	}
+	used, err := s.Used(i)
+	if err != nil {
+		return 0, err
+	}
+	state := &s.states[i]
+	refilled := min(n, len(used))
+	for _, instance := range used[:refilled] {
+		delete(state.UsedInstances, instance)
+		state.RemainingSupply++
+		if state.unused != nil {
+			state.unused.add(instance-1, 1)
+		}
+	}
+	return refilled, nil
+}
@@ -0,0 +1,167 @@
+package randomness
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestSelectorDepletes(t *testing.T) {
+	items := []Item{
+		&testItem{value: 1, weight: 1.0, supply: 3},
+		&testItem{value: 2, weight: 1.0, supply: 2},
+	}
+	s, err := NewSelector(items)
+	if err != nil {
+		t.Fatalf("NewSelector() error = %v", err)
+	}
+
+	r := NewRandomness(BetaValues(GenerateTestRandomValue()))
+	seen := make(map[[2]int]bool)
+	for range 5 {
+		results, err := s.Draw(r, 1)
+		if err != nil {
+			t.Fatalf("Draw() error = %v", err)
+		}
+		key := [2]int{results[0].Get().(*testItem).value, results[0].Instance()}
+		if seen[key] {
+			t.Errorf("instance %d of item %d was drawn twice", key[1], key[0])
+		}
+		seen[key] = true
+	}
+
+	if remaining(t, s, 0) != 0 || remaining(t, s, 1) != 0 {
+		t.Errorf("Remaining() = %d, %d, want 0, 0", remaining(t, s, 0), remaining(t, s, 1))
+	}
+	if !slices.Equal(used(t, s, 0), []int{1, 2, 3}) {
+		t.Errorf("Used(0) = %v, want [1 2 3]", used(t, s, 0))
+	}
+	if _, err := s.Draw(r, 1); err == nil {
+		t.Error("Draw() from an exhausted pool did not return an error")
+	}
+
+	if n, err := s.Refill(0, 2); err != nil || n != 2 {
+		t.Errorf("Refill(0, 2) = %d, %v, want 2", n, err)
+	}
+	if remaining(t, s, 0) != 2 || !slices.Equal(used(t, s, 0), []int{3}) {
+		t.Errorf("after Refill() Remaining(0) = %d, Used(0) = %v", remaining(t, s, 0), used(t, s, 0))
+	}
+	results, err := s.Draw(r, 2)
+	if err != nil {
+		t.Fatalf("Draw() after Refill() error = %v", err)
+	}
+	for _, result := range results {
+		if result.Get().(*testItem).value != 1 || result.Instance() == 3 {
+			t.Errorf("Draw() after Refill() returned item %d instance %d", result.Get().(*testItem).value, result.Instance())
+		}
+	}
+
+	s.Reset()
+	if remaining(t, s, 0) != 3 || remaining(t, s, 1) != 2 || len(used(t, s, 0)) != 0 {
+		t.Errorf("after Reset() Remaining() = %d, %d", remaining(t, s, 0), remaining(t, s, 1))
+	}
+}
+
+func TestSelectorMatchesSelection(t *testing.T) {
+	items := goldenItems()
+	s, err := NewSelector(items)
+	if err != nil {
+		t.Fatalf("NewSelector() error = %v", err)
+	}
+
+	// Two draws of two from a Selector match one Selection of four.
+	r := NewRandomness(BetaBytes("test"))
+	first, _ := s.Draw(r, 2)
+	second, _ := s.Draw(r, 2)
+	got := append(first, second...)
+
+	want, _ := NewRandomness(BetaBytes("test")).Selection(SelectionConfig{Items: items, Count: 4})
+	for i := range want {
+		if got[i].Any() != want[i].Any() || got[i].Instance() != want[i].Instance() {
+			t.Errorf("draw %d = %v/%d, want %v/%d", i, got[i].Any(), got[i].Instance(), want[i].Any(), want[i].Instance())
+		}
+	}
+
+	if remaining(t, s, 2) != -1 {
+		t.Errorf("Remaining() of an infinite item = %d, want -1", remaining(t, s, 2))
+	}
+}
+
+func TestNewSelectorErrors(t *testing.T) {
+	if _, err := NewSelector(nil); err == nil {
+		t.Error("NewSelector(nil) did not return an error")
+	}
+	if _, err := NewSelector([]Item{&testItem{weight: -1, supply: 1}}); err == nil {
+		t.Error("NewSelector() accepted a negative weight")
+	}
+}
+
+func TestSelectorIndexOutOfRange(t *testing.T) {
+	s, _ := NewSelector([]Item{&testItem{value: 1, weight: 1.0, supply: 1}})
+	for _, i := range []int{-1, 1} {
+		if _, err := s.Remaining(i); err == nil {
+			t.Errorf("Remaining(%d) did not return an error", i)
+		}
+		if _, err := s.Used(i); err == nil {
+			t.Errorf("Used(%d) did not return an error", i)
+		}
+		if _, err := s.Refill(i, 1); err == nil {
+			t.Errorf("Refill(%d, 1) did not return an error", i)
+		}
+	}
+	for _, n := range []int{-1, 0} {
+		if _, err := s.Refill(0, n); err == nil {
+			t.Errorf("Refill(0, %d) did not return an error", n)
+		}
+	}
+}
+
+// failingSelection makes the selection it is asked for, then fails.
+type failingSelection struct {
+	Randomness
+}
+
+func (r failingSelection) Selection(cfg SelectionConfig) ([]SelectionResult, error) {
+	if _, err := r.Randomness.Selection(cfg); err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("selection failed")
+}
+
+func TestSelectorDrawIsAtomic(t *testing.T) {
+	s, err := NewSelector([]Item{&testItem{value: 1, weight: 1.0, supply: 3}})
+	if err != nil {
+		t.Fatalf("NewSelector() error = %v", err)
+	}
+	r := NewRandomness(BetaBytes("test"))
+	s.Draw(r, 1)
+	if _, err := s.Draw(failingSelection{r}, 2); err == nil {
+		t.Fatal("Draw() did not return the selection error")
+	}
+	if remaining(t, s, 0) != 2 || len(used(t, s, 0)) != 1 {
+		t.Errorf("after a failed Draw() Remaining(0) = %d, Used(0) = %v, want 2 and one instance", remaining(t, s, 0), used(t, s, 0))
+	}
+	if _, err := s.Draw(r, 2); err != nil {
+		t.Errorf("Draw() after a failed Draw() error = %v", err)
+	}
+}
+
+// remaining returns the remaining supply of item i of s.
+func remaining(t *testing.T, s *Selector, i int) int {
+	t.Helper()
+	n, err := s.Remaining(i)
+	if err != nil {
+		t.Fatalf("Remaining(%d) error = %v", i, err)
+	}
+	return n
+}
+
+// used returns the drawn instances of item i of s.
+func used(t *testing.T, s *Selector, i int) []int {
+	t.Helper()
+	u, err := s.Used(i)
+	if err != nil {
+		t.Fatalf("Used(%d) error = %v", i, err)
+	}
+	return u
+}
@@ -0,0 +1,43 @@
+package randomness
+
+import "fmt"
+
+// Shuffle permutes s in place with the Fisher–Yates shuffle. For i from
+// len(s)-1 down to 1 it draws j uniformly from [0, i] and swaps s[i] and
+// s[j]. Each j is drawn with the rejection method of SamplingRejection (see
+// uniform), whatever the algorithm version and sampling of r, so the shuffle
+// is unbiased and consumes one Uint64 per swap plus one for each rejected
+// read. The order of consumption does not depend on math/rand.
+func Shuffle[T any](r Randomness, s []T) error {
+	if b, ok := r.(*randomness); ok {
+		defer b.record("Shuffle", &s)()
+	}
+	return shuffle(r, s)
+}
+
+func shuffle[T any](r Randomness, s []T) error {
+	for i := len(s) - 1; i > 0; i-- {
+		j, err := uniform(r, uint64(i+1))
+		if err != nil {
+			return err
+		}
+		s[i], s[j] = s[j], s[i]
+	}
+	return nil
+}
+
+// Permutation returns a random permutation of the integers [0, n). It is the
+// result of Shuffle on the slice 0, 1, ..., n-1 and consumes the same bytes.
+func Permutation(r Randomness, n int) (p []int, err error) {
+	if n < 0 {
+		return nil, fmt.Errorf("cannot permute %d numbers: n must be non-negative", n)
+	}
+	if b, ok := r.(*randomness); ok {
+		defer b.record("Permutation", &p)()
+	}
+	p = make([]int, n)
+	for i := range p {
+		p[i] = i
+	}
+	return p, shuffle(r, p)
+}
@@ -0,0 +1,60 @@
+package randomness
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPermutation(t *testing.T) {
+	// Every version permutes the start of the stream the same way.
+	for v := AlgorithmV1; v <= AlgorithmLatest; v++ {
+		r, _ := NewRandomnessV(v, BetaBytes("test"))
+		p, err := Permutation(r, 10)
+		if err != nil {
+			t.Fatalf("Permutation() error = %v", err)
+		}
+		if want := []int{1, 6, 5, 8, 0, 9, 2, 3, 7, 4}; !slices.Equal(p, want) {
+			t.Errorf("Permutation() with %s = %v, want %v", v, p, want)
+		}
+	}
+
+	// Shuffle consumes the same bytes and moves the elements the same way.
+	p, _ := Permutation(NewRandomness(BetaBytes("test")), 10)
+	s := []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j"}
+	if err := Shuffle(NewRandomness(BetaBytes("test")), s); err != nil {
+		t.Fatalf("Shuffle() error = %v", err)
+	}
+	for i, v := range p {
+		if s[i] != string(rune('a'+v)) {
+			t.Errorf("Shuffle()[%d] = %s, want %c", i, s[i], 'a'+v)
+		}
+	}
+
+	if _, err := Permutation(NewRandomness(BetaBytes("test")), -1); err == nil {
+		t.Error("Permutation() accepted a negative length")
+	}
+	if p, err := Permutation(NewRandomness(BetaBytes("test")), 0); err != nil || len(p) != 0 {
+		t.Errorf("Permutation(0) = %v, %v", p, err)
+	}
+}
+
+func TestShuffleIsUniform(t *testing.T) {
+	r := NewRandomness(BetaBytes("shuffle"))
+	counts := make(map[[3]int]int)
+	const rounds = 60000
+	for range rounds {
+		s := []int{0, 1, 2}
+		if err := Shuffle(r, s); err != nil {
+			t.Fatalf("Shuffle() error = %v", err)
+		}
+		counts[[3]int(s)]++
+	}
+	if len(counts) != 6 {
+		t.Fatalf("Shuffle() produced %d permutations, want 6", len(counts))
+	}
+	for p, count := range counts {
+		if count < rounds/6*95/100 || count > rounds/6*105/100 {
+			t.Errorf("Shuffle() produced %v %d times, want about %d", p, count, rounds/6)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+// Package simulate runs Monte Carlo simulations of games played with
+// randomness.Randomness and reports their return to player and variance.
+package simulate
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/revision-3/randomness"
+)
+
+// Game plays a single round with r and returns the payout per unit staked:
+// 0 for a loss, 1 to return the stake, 2.5 for a win paying 3 to 2 and so on.
+type Game func(r randomness.Randomness) (float64, error)
+
+// Config configures a simulation.
+type Config struct {
+	// Rounds is the number of rounds to play.
+	Rounds int
+
+	// Workers is the number of goroutines playing rounds. It defaults to
+	// runtime.GOMAXPROCS(0).
+	Workers int
+
+	// Seed derives the beta of every round: round i uses
+	// randomness.ForkBeta(Seed, "round:"+i). If it is empty a random seed
+	// is generated; either way it is returned in the Report so the
+	// simulation can be reproduced.
+	Seed randomness.BetaBytes
+
+	// Algorithm is the algorithm version of every round. It defaults to
+	// randomness.AlgorithmLatest.
+	Algorithm randomness.Algorithm
+
+	// Options are applied to the Randomness of every round.
+	Options []randomness.Option
+
+	// Confidence is the confidence level of the interval reported for the
+	// return to player. It defaults to 0.95.
+	Confidence float64
+}
+
+// Report summarises a simulation.
+type Report struct {
+	Rounds    int
+	Seed      randomness.BetaBytes
+	Algorithm randomness.Algorithm
+
+	// RTP is the return to player: the mean payout per unit staked.
+	RTP float64
+
+	// HitFrequency is the fraction of rounds with a non-zero payout.
+	HitFrequency float64
+
+	// Variance and StdDev are the sample variance and standard deviation
+	// of the payout of a round.
+	Variance float64
+	StdDev   float64
+
+	// Confidence is the confidence level of the interval [Low, High]
+	// for the RTP, using the normal approximation.
+	Confidence float64
+	Low        float64
+	High       float64
+
+	// MaxPayout is the largest payout of any round.
+	MaxPayout float64
+
+	// Payouts counts the rounds for each distinct payout.
+	Payouts map[float64]int
+}
+
+// RoundBeta returns the beta of round i of a simulation with the given seed.
+func RoundBeta(seed randomness.BetaBytes, i int) randomness.BetaBytes {
+	return randomness.ForkBeta(seed, "round:"+strconv.Itoa(i))
+}
+
+// Run plays cfg.Rounds rounds of game in parallel, each with a fresh
+// Randomness, and aggregates their payouts. The rounds are split into one
+// contiguous block per worker and the blocks are merged in order, so the same
+// seed and number of workers always give the same report. Run stops at the
+// first error returned by game or when ctx is cancelled.
+func Run(ctx context.Context, cfg Config, game Game) (*Report, error) {
+	if cfg.Rounds <= 0 {
+		return nil, fmt.Errorf("rounds must be positive")
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = runtime.GOMAXPROCS(0)
+	}
+	cfg.Workers = min(cfg.Workers, cfg.Rounds)
+	if cfg.Algorithm == 0 {
+		cfg.Algorithm = randomness.AlgorithmLatest
+	}
+	if !cfg.Algorithm.Valid() {
+		return nil, fmt.Errorf("unknown algorithm version %s", cfg.Algorithm)
+	}
+	if cfg.Confidence == 0 {
+		cfg.Confidence = 0.95
+	}
+	if cfg.Confidence <= 0 || cfg.Confidence >= 1 {
+		return nil, fmt.Errorf("confidence must be in (0, 1)")
+	}
+	if len(cfg.Seed) == 0 {
+		seed, err := randomness.GenerateServerSeed()
+		if err != nil {
+			return nil, err
+		}
+		cfg.Seed = randomness.MustBetaBytesFromHex(seed)
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	tallies := make([]tally, cfg.Workers)
+	var wg sync.WaitGroup
+	for w := range cfg.Workers {
+		from, to := cfg.Rounds*w/cfg.Workers, cfg.Rounds*(w+1)/cfg.Workers
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			t := &tallies[w]
+			t.payouts = make(map[float64]int)
+			for i := from; i < to; i++ {
+				if ctx.Err() != nil {
+					return
+				}
+				r, err := randomness.NewRandomnessV(cfg.Algorithm, RoundBeta(cfg.Seed, i), cfg.Options...)
+				if err != nil {
+					cancel(err)
+					return
+				}
+				payout, err := game(r)
+				if err != nil {
+					cancel(fmt.Errorf("round %d: %w", i, err))
+					return
+				}
+				t.add(payout)
+			}
+		}()
+	}
+	wg.Wait()
+	if err := context.Cause(ctx); err != nil {
+		return nil, err
+	}
+
+	total := tally{payouts: make(map[float64]int)}
+	for _, t := range tallies {
+		total.merge(&t)
+	}
+	return total.report(cfg), nil
+}
+
+// tally accumulates payouts with Welford's algorithm.
+type tally struct {
+	n       int
+	hits    int
+	mean    float64
+	m2      float64 // Sum of squared differences from the mean
+	max     float64
+	payouts map[float64]int
+}
+
+func (t *tally) add(payout float64) {
+	t.n++
+	if payout != 0 {
+		t.hits++
+	}
+	delta := payout - t.mean
+	t.mean += delta / float64(t.n)
+	t.m2 += delta * (payout - t.mean)
+	if t.n == 1 || payout > t.max {
+		t.max = payout
+	}
+	t.payouts[payout]++
+}
+
+// merge combines the tally of another worker into t.
+func (t *tally) merge(o *tally) {
+	if o.n == 0 {
+		return
+	}
+	if t.n == 0 {
+		t.max = o.max
+	}
+	n := t.n + o.n
+	delta := o.mean - t.mean
+	t.mean += delta * float64(o.n) / float64(n)
+	t.m2 += o.m2 + delta*delta*float64(t.n)*float64(o.n)/float64(n)
+	t.n = n
+	t.hits += o.hits
+	t.max = max(t.max, o.max)
+	for payout, count := range o.payouts {
+		t.payouts[payout] += count
+	}
+}
+
+func (t *tally) report(cfg Config) *Report {
+	r := &Report{
+		Rounds:       t.n,
+		Seed:         cfg.Seed,
+		Algorithm:    cfg.Algorithm,
+		RTP:          t.mean,
+		HitFrequency: float64(t.hits) / float64(t.n),
+		Confidence:   cfg.Confidence,
+		MaxPayout:    t.max,
+		Payouts:      t.payouts,
+	}
+	if t.n > 1 {
+		r.Variance = t.m2 / float64(t.n-1)
+	}
+	r.StdDev = math.Sqrt(r.Variance)
+	z := math.Sqrt2 * math.Erfinv(cfg.Confidence)
+	margin := z * r.StdDev / math.Sqrt(float64(t.n))
+	r.Low, r.High = r.RTP-margin, r.RTP+margin
+	return r
+}
+
+// String formats the report for display.
+func (r *Report) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "rounds:        %d\n", r.Rounds)
+	fmt.Fprintf(&b, "seed:          %s\n", r.Seed)
+	fmt.Fprintf(&b, "algorithm:     %s\n", r.Algorithm)
+	fmt.Fprintf(&b, "RTP:           %.6f\n", r.RTP)
+	fmt.Fprintf(&b, "%g%% interval: [%.6f, %.6f]\n", r.Confidence*100, r.Low, r.High)
+	fmt.Fprintf(&b, "hit frequency: %.6f\n", r.HitFrequency)
+	fmt.Fprintf(&b, "variance:      %.6f\n", r.Variance)
+	fmt.Fprintf(&b, "std dev:       %.6f\n", r.StdDev)
+	fmt.Fprintf(&b, "max payout:    %g\n", r.MaxPayout)
+
+	payouts := make([]float64, 0, len(r.Payouts))
+	for payout := range r.Payouts {
+		payouts = append(payouts, payout)
+	}
+	sort.Float64s(payouts)
+	for _, payout := range payouts {
+		fmt.Fprintf(&b, "  %10g  %d\n", payout, r.Payouts[payout])
+	}
+	return b.String()
+}
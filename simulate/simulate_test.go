@@ -0,0 +1,100 @@
+package simulate
+
+import (
+	"context"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/revision-3/randomness"
+)
+
+// coin pays 2 on heads and 0 on tails, for an RTP of 1 and a variance of 1.
+func coin(r randomness.Randomness) (float64, error) {
+	heads, err := r.IntN(2)
+	if err != nil {
+		return 0, err
+	}
+	return float64(heads * 2), nil
+}
+
+func TestRun(t *testing.T) {
+	report, err := Run(context.Background(), Config{
+		Rounds:     100000,
+		Workers:    4,
+		Seed:       randomness.BetaBytes("simulate"),
+		Confidence: 0.9999,
+	}, coin)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if report.Rounds != 100000 {
+		t.Errorf("Rounds = %d, want 100000", report.Rounds)
+	}
+	if report.Low > 1 || report.High < 1 {
+		t.Errorf("RTP interval [%f, %f] does not contain 1", report.Low, report.High)
+	}
+	if math.Abs(report.HitFrequency-0.5) > 0.01 {
+		t.Errorf("HitFrequency = %f, want 0.5", report.HitFrequency)
+	}
+	if math.Abs(report.Variance-1) > 0.01 {
+		t.Errorf("Variance = %f, want 1", report.Variance)
+	}
+	if report.MaxPayout != 2 {
+		t.Errorf("MaxPayout = %f, want 2", report.MaxPayout)
+	}
+	if report.Payouts[0]+report.Payouts[2] != report.Rounds {
+		t.Errorf("Payouts = %v, want only 0 and 2", report.Payouts)
+	}
+}
+
+func TestRunIsReproducible(t *testing.T) {
+	cfg := Config{Rounds: 1000, Workers: 3, Seed: randomness.BetaBytes("simulate")}
+	a, err := Run(context.Background(), cfg, coin)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	b, err := Run(context.Background(), cfg, coin)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if !reflect.DeepEqual(a, b) {
+		t.Errorf("Run() = %+v then %+v with the same seed", a, b)
+	}
+
+	// Every round can be replayed from its beta, giving the same payouts.
+	payouts := make(map[float64]int)
+	for i := range cfg.Rounds {
+		r, err := randomness.NewRandomnessV(randomness.AlgorithmLatest, RoundBeta(cfg.Seed, i))
+		if err != nil {
+			t.Fatalf("NewRandomnessV() error = %v", err)
+		}
+		payout, err := coin(r)
+		if err != nil {
+			t.Fatalf("coin() error = %v", err)
+		}
+		payouts[payout]++
+	}
+	if !reflect.DeepEqual(payouts, a.Payouts) {
+		t.Errorf("replayed payouts = %v, want %v", payouts, a.Payouts)
+	}
+}
+
+func TestRunError(t *testing.T) {
+	fail := errors.New("fail")
+	_, err := Run(context.Background(), Config{Rounds: 100, Workers: 2}, func(r randomness.Randomness) (float64, error) {
+		return 0, fail
+	})
+	if !errors.Is(err, fail) {
+		t.Errorf("Run() error = %v, want %v", err, fail)
+	}
+
+	if _, err := Run(context.Background(), Config{}, coin); err == nil {
+		t.Error("Run() accepted zero rounds")
+	}
+	if _, err := Run(context.Background(), Config{Rounds: 1, Confidence: 2}, coin); err == nil {
+		t.Error("Run() accepted a confidence of 2")
+	}
+}
@@ -0,0 +1,191 @@
+package randomness
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// stateFormat is the version of the encoding produced by MarshalBinary.
+// Format 1 predates StreamBits and is still accepted by UnmarshalBinary.
+const stateFormat = 2
+
+// MarshalBinary encodes the cursor state of the Randomness: the algorithm
+// version, sampling, stream, amplifier identifier, beta, position and
+// amplification counter. The expanded stream is not stored; it is recreated
+// from the beta on restore. A transcript, if any, is not part of the state.
+//
+// The encoding is a format version byte followed by uvarints, with the
+// amplifier identifier and the beta each prefixed by their length:
+//
+//	format | algorithm | sampling | stream | len(amplifier) amplifier | len(β) β | pos | bit | amplification
+//
+// Format 1 has no stream and bit fields.
+func (b *randomness) MarshalBinary() ([]byte, error) {
+	id := b.amplifier.ID()
+	buf := []byte{stateFormat}
+	buf = binary.AppendUvarint(buf, uint64(b.algorithm))
+	buf = binary.AppendUvarint(buf, uint64(b.sampling))
+	buf = binary.AppendUvarint(buf, uint64(b.stream))
+	buf = binary.AppendUvarint(buf, uint64(len(id)))
+	buf = append(buf, id...)
+	buf = binary.AppendUvarint(buf, uint64(len(b.beta)))
+	buf = append(buf, b.beta...)
+	buf = binary.AppendUvarint(buf, uint64(b.pos))
+	buf = binary.AppendUvarint(buf, uint64(b.bit))
+	buf = binary.AppendUvarint(buf, uint64(b.amplification))
+	return buf, nil
+}
+
+// UnmarshalBinary restores the cursor state encoded by MarshalBinary. The
+// amplifier must be registered, see RegisterAmplifier.
+func (b *randomness) UnmarshalBinary(data []byte) error {
+	r := bytes.NewReader(data)
+	format, err := r.ReadByte()
+	if err != nil {
+		return fmt.Errorf("cannot restore state: %w", err)
+	}
+	if format != 1 && format != stateFormat {
+		return fmt.Errorf("cannot restore state: unknown format %d", format)
+	}
+
+	fields := make([]uint64, 3)
+	if format == 1 {
+		fields = fields[:2] // No stream, so StreamBytes
+	}
+	for i := range fields {
+		if fields[i], err = binary.ReadUvarint(r); err != nil {
+			return fmt.Errorf("cannot restore state: %w", err)
+		}
+	}
+	fields = fields[:3]
+	algorithm, sampling, stream := Algorithm(fields[0]), Sampling(fields[1]), Stream(fields[2])
+	if !algorithm.Valid() {
+		return fmt.Errorf("cannot restore state: unknown algorithm version %s", algorithm)
+	}
+	if sampling != SamplingAccumulator && sampling != SamplingRejection {
+		return fmt.Errorf("cannot restore state: unknown sampling %s", sampling)
+	}
+	if stream != StreamBytes && stream != StreamBits {
+		return fmt.Errorf("cannot restore state: unknown stream %s", stream)
+	}
+
+	id, err := readState(r)
+	if err != nil {
+		return err
+	}
+	amplifier, err := AmplifierByID(string(id))
+	if err != nil {
+		return fmt.Errorf("cannot restore state: %w", err)
+	}
+	β, err := readState(r)
+	if err != nil {
+		return err
+	}
+
+	pos, err := binary.ReadUvarint(r)
+	if err != nil {
+		return fmt.Errorf("cannot restore state: %w", err)
+	}
+	var bit uint64
+	if format != 1 {
+		if bit, err = binary.ReadUvarint(r); err != nil {
+			return fmt.Errorf("cannot restore state: %w", err)
+		}
+		if bit > 7 {
+			return fmt.Errorf("cannot restore state: invalid bit offset %d", bit)
+		}
+	}
+	amplification, err := binary.ReadUvarint(r)
+	if err != nil {
+		return fmt.Errorf("cannot restore state: %w", err)
+	}
+	if r.Len() != 0 {
+		return fmt.Errorf("cannot restore state: %d trailing bytes", r.Len())
+	}
+	if err := checkState(amplifier, β, pos, amplification); err != nil {
+		return err
+	}
+
+	restored := newRandomness(algorithm, β, WithSampling(sampling), WithStream(stream), WithAmplifier(amplifier))
+	if err := restored.seek(int(pos)); err != nil {
+		return fmt.Errorf("cannot restore state: %w", err)
+	}
+	if bit != 0 {
+		if err := restored.need(1); err != nil {
+			return fmt.Errorf("cannot restore state: %w", err)
+		}
+		restored.bit = uint8(bit)
+	}
+	if uint64(restored.amplification) > amplification {
+		return fmt.Errorf("cannot restore state: position %d is beyond amplification %d", pos, amplification)
+	}
+	for uint64(restored.amplification) < amplification {
+		if err := restored.amplify(); err != nil {
+			return fmt.Errorf("cannot restore state: %w", err)
+		}
+	}
+	restored.transcript = b.transcript
+	*b = *restored
+	return nil
+}
+
+// checkState rejects a position and amplification counter that no stream
+// of the amplifier could have produced, before any of it is amplified. The
+// stream always holds the position and never more than one whole block past
+// it, so the counter is within one block of the position. The restore then
+// seeks straight to the block holding the position, so its cost does not
+// grow with the position.
+func checkState(amplifier Amplifier, β []byte, pos, amplification uint64) error {
+	if pos > math.MaxInt {
+		return fmt.Errorf("cannot restore state: position %d out of range", pos)
+	}
+	size := uint64(len(amplifier.Block(β, 1)))
+	if size == 0 {
+		return fmt.Errorf("cannot restore state: amplifier %q returned an empty block", amplifier.ID())
+	}
+	var blocks uint64
+	if pos > uint64(len(β)) {
+		blocks = (pos - uint64(len(β)) + size - 1) / size
+	}
+	if amplification < blocks {
+		return fmt.Errorf("cannot restore state: position %d is beyond amplification %d", pos, amplification)
+	}
+	if amplification > blocks+1 {
+		return fmt.Errorf("cannot restore state: amplification %d is beyond position %d", amplification, pos)
+	}
+	return nil
+}
+
+// readState reads a length prefixed byte slice.
+func readState(r *bytes.Reader) ([]byte, error) {
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, fmt.Errorf("cannot restore state: %w", err)
+	}
+	if n > uint64(r.Len()) {
+		return nil, fmt.Errorf("cannot restore state: %w", io.ErrUnexpectedEOF)
+	}
+	buf := make([]byte, n)
+	r.Read(buf)
+	return buf, nil
+}
+
+// RestoreRandomness creates a Randomness from the state encoded by
+// Randomness.MarshalBinary, continuing exactly where it left off. Options are
+// applied after the state is restored, e.g. to attach a new transcript.
+func RestoreRandomness(state []byte, opts ...Option) (Randomness, error) {
+	b := &randomness{}
+	if err := b.UnmarshalBinary(state); err != nil {
+		return nil, err
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	if b.transcript != nil {
+		b.transcript.begin(b)
+	}
+	return b, nil
+}
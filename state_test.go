@@ -0,0 +1,173 @@
+package randomness
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func TestMarshalBinary(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		opts []Option
+	}{
+		{"default", nil},
+		{"rejection", []Option{WithSampling(SamplingRejection)}},
+		{"shake256", []Option{WithAmplifier(AmplifierSHAKE256)}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := NewRandomnessV(AlgorithmV2, BetaBytes("test"), tt.opts...)
+			if err != nil {
+				t.Fatalf("NewRandomnessV() error = %v", err)
+			}
+			control, _ := NewRandomnessV(AlgorithmV2, BetaBytes("test"), tt.opts...)
+
+			// Draw across several amplifications before saving.
+			for range 20 {
+				r.Uint64()
+				control.Uint64()
+			}
+			r.Uint8()
+			control.Uint8()
+
+			state, err := r.MarshalBinary()
+			if err != nil {
+				t.Fatalf("MarshalBinary() error = %v", err)
+			}
+			restored, err := RestoreRandomness(state)
+			if err != nil {
+				t.Fatalf("RestoreRandomness() error = %v", err)
+			}
+			if restored.Algorithm() != r.Algorithm() || restored.Amplifier() != r.Amplifier() {
+				t.Errorf("restored %s/%s, want %s/%s", restored.Algorithm(), restored.Amplifier().ID(),
+					r.Algorithm(), r.Amplifier().ID())
+			}
+
+			want, _ := control.Pick(10, 37)
+			got, err := restored.Pick(10, 37)
+			if err != nil {
+				t.Fatalf("Pick() error = %v", err)
+			}
+			for i := range want {
+				if got[i] != want[i] {
+					t.Errorf("Pick()[%d] = %d, want %d", i, got[i], want[i])
+				}
+			}
+
+			wantBytes, _ := control.Bytes(200)
+			gotBytes, _ := restored.Bytes(200)
+			if !bytes.Equal(gotBytes, wantBytes) {
+				t.Errorf("Bytes() after restore = %x, want %x", gotBytes, wantBytes)
+			}
+		})
+	}
+}
+
+func TestRestoreRandomnessErrors(t *testing.T) {
+	r := NewRandomness(BetaBytes("test"))
+	r.Uint64()
+	state, _ := r.MarshalBinary()
+
+	tests := []struct {
+		name  string
+		state []byte
+	}{
+		{"empty", nil},
+		{"unknown format", append([]byte{99}, state[1:]...)},
+		{"truncated", state[:len(state)-2]},
+		{"trailing bytes", append(append([]byte(nil), state...), 0)},
+		{"unknown algorithm", append([]byte{stateFormat, 99}, state[2:]...)},
+		{"unknown sampling", append([]byte{stateFormat, state[1], 99}, state[3:]...)},
+		{"unknown stream", append([]byte{stateFormat, state[1], state[2], 99}, state[4:]...)},
+		{"position out of range", encodeState(1<<63, 0, 0)},
+		{"amplification beyond position", encodeState(8, 0, 1<<40)},
+		{"position beyond amplification", encodeState(1<<34, 0, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := RestoreRandomness(tt.state); err == nil {
+				t.Error("RestoreRandomness() did not return an error")
+			}
+		})
+	}
+}
+
+// encodeState encodes a state of the "test" beta with the given cursor.
+func encodeState(pos, bit, amplification uint64) []byte {
+	return appendCursor(stateHeader(), pos, bit, amplification)
+}
+
+// stateHeader returns the encoded fields of a state up to the position.
+func stateHeader() []byte {
+	buf := []byte{stateFormat, byte(AlgorithmLatest), byte(SamplingRejection), byte(StreamBytes)}
+	buf = binary.AppendUvarint(buf, uint64(len("sha512")))
+	buf = append(buf, "sha512"...)
+	buf = binary.AppendUvarint(buf, 4)
+	return append(buf, "test"...)
+}
+
+func appendCursor(buf []byte, pos, bit, amplification uint64) []byte {
+	buf = binary.AppendUvarint(buf, pos)
+	buf = binary.AppendUvarint(buf, bit)
+	return binary.AppendUvarint(buf, amplification)
+}
+
+// FuzzRestoreRandomness fuzzes the header and the cursor separately, so the
+// cursor fields are explored whatever the header.
+func FuzzRestoreRandomness(f *testing.F) {
+	f.Add(stateHeader(), uint64(8), uint64(0), uint64(0))
+	f.Add(stateHeader(), uint64(300), uint64(3), uint64(4))
+	f.Add(stateHeader(), uint64(0), uint64(0), uint64(1<<40))
+	f.Add(stateHeader(), uint64(1<<34), uint64(0), uint64(0))
+	f.Add(stateHeader(), uint64(1<<62), uint64(5), uint64(1<<56))
+	f.Fuzz(func(t *testing.T, header []byte, pos, bit, amplification uint64) {
+		r, err := RestoreRandomness(appendCursor(header, pos, bit, amplification))
+		if err != nil {
+			return
+		}
+		state, err := r.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := RestoreRandomness(state); err != nil {
+			t.Fatalf("RestoreRandomness() of a marshalled state = %v", err)
+		}
+	})
+}
+
+func TestRestoreWithTranscript(t *testing.T) {
+	r := NewRandomness(BetaBytes("test"))
+	r.Bytes(100)
+	state, _ := r.MarshalBinary()
+
+	var transcript Transcript
+	restored, err := RestoreRandomness(state, WithTranscript(&transcript))
+	if err != nil {
+		t.Fatalf("RestoreRandomness() error = %v", err)
+	}
+	restored.Uint32()
+	if len(transcript.Entries) != 1 || transcript.Entries[0].Offset != 100 {
+		t.Fatalf("transcript entries = %+v, want one at offset 100", transcript.Entries)
+	}
+	if err := transcript.Verify(); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+}
+
+func TestRestoreLongSession(t *testing.T) {
+	// The position is 60 bytes into block k+1, which has been amplified.
+	const pos = 1 << 40
+	k := uint64(pos-4) / 64
+	r, err := RestoreRandomness(encodeState(pos, 0, k+1))
+	if err != nil {
+		t.Fatalf("RestoreRandomness() error = %v", err)
+	}
+	got, err := r.Bytes(8)
+	if err != nil {
+		t.Fatalf("Bytes() error = %v", err)
+	}
+	want := append(AmplifierSHA512.Block([]byte("test"), k+1)[60:], AmplifierSHA512.Block([]byte("test"), k+2)[:4]...)
+	if !bytes.Equal(got, want) {
+		t.Errorf("Bytes() = %x, want %x", got, want)
+	}
+}
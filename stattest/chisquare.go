@@ -0,0 +1,97 @@
+package stattest
+
+import (
+	"fmt"
+
+	"github.com/revision-3/randomness"
+)
+
+// ChiSquare returns the p-value of Pearson's chi-square goodness-of-fit test
+// of observed counts against expected counts. Categories expected to be empty
+// must be observed empty and do not count towards the degrees of freedom.
+func ChiSquare(observed []int, expected []float64) (float64, error) {
+	if len(observed) != len(expected) {
+		return 0, fmt.Errorf("observed and expected counts differ in length")
+	}
+	chi := 0.0
+	categories := 0
+	for i, e := range expected {
+		if e <= 0 {
+			if observed[i] != 0 {
+				return 0, nil
+			}
+			continue
+		}
+		d := float64(observed[i]) - e
+		chi += d * d / e
+		categories++
+	}
+	if categories < 2 {
+		return 0, fmt.Errorf("chi-square test needs at least two categories")
+	}
+	return igamc(float64(categories-1)/2, chi/2), nil
+}
+
+// ChiSquarePick draws samples integers in [0, magnitude) with Pick and
+// returns the p-value of the chi-square test of their counts against the
+// uniform distribution.
+func ChiSquarePick(r randomness.Randomness, samples, magnitude int) (float64, error) {
+	values, err := r.Pick(samples, magnitude)
+	if err != nil {
+		return 0, err
+	}
+	observed := make([]int, magnitude)
+	for _, v := range values {
+		observed[v]++
+	}
+	expected := make([]float64, magnitude)
+	for i := range expected {
+		expected[i] = float64(samples) / float64(magnitude)
+	}
+	return ChiSquare(observed, expected)
+}
+
+// ChiSquareSelection runs rounds selections of cfg and returns, for every
+// position in a selection, the p-value of the chi-square test of the items
+// selected there against the odds given by randomness.CalculateOdds. Every
+// round starts from the full supply, so cfg must not carry ItemStates. The
+// items must be distinct comparable values, such as pointers.
+func ChiSquareSelection(r randomness.Randomness, cfg randomness.SelectionConfig, rounds int) ([]float64, error) {
+	if cfg.ItemStates != nil {
+		return nil, fmt.Errorf("chi-square test of selection cannot carry item states across rounds")
+	}
+	odds, err := randomness.CalculateOdds(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	index := make(map[randomness.Item]int, len(cfg.Items))
+	for i, item := range cfg.Items {
+		index[item] = i
+	}
+	observed := make([][]int, cfg.Count)
+	for d := range observed {
+		observed[d] = make([]int, len(cfg.Items))
+	}
+	for range rounds {
+		results, err := r.Selection(cfg)
+		if err != nil {
+			return nil, err
+		}
+		for d, result := range results {
+			observed[d][index[result.Get()]]++
+		}
+	}
+
+	pvalues := make([]float64, cfg.Count)
+	for d := range pvalues {
+		expected := make([]float64, len(cfg.Items))
+		for i := range expected {
+			expected[i] = odds.Position(d, i) * float64(rounds)
+		}
+		if pvalues[d], err = ChiSquare(observed[d], expected); err != nil {
+			return nil, fmt.Errorf("position %d: %w", d, err)
+		}
+	}
+	return pvalues, nil
+}
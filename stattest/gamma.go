@@ -0,0 +1,73 @@
+package stattest
+
+import "math"
+
+// igamc returns the regularized upper incomplete gamma function Q(a, x), the
+// probability that a chi-square variable with 2a degrees of freedom exceeds
+// 2x. It uses the series expansion of P(a, x) below a+1 and a continued
+// fraction for Q(a, x) above, as in Numerical Recipes.
+func igamc(a, x float64) float64 {
+	switch {
+	case x <= 0:
+		return 1
+	case x < a+1:
+		return 1 - igamSeries(a, x)
+	default:
+		return igamcFraction(a, x)
+	}
+}
+
+const (
+	gammaIterations = 1000
+	gammaEpsilon    = 1e-15
+	gammaTiny       = 1e-300
+)
+
+// igamSeries returns P(a, x) by its series expansion.
+func igamSeries(a, x float64) float64 {
+	lg, _ := math.Lgamma(a)
+	term := 1 / a
+	sum := term
+	for n := 1; n < gammaIterations; n++ {
+		term *= x / (a + float64(n))
+		sum += term
+		if math.Abs(term) < math.Abs(sum)*gammaEpsilon {
+			break
+		}
+	}
+	return sum * math.Exp(-x+a*math.Log(x)-lg)
+}
+
+// igamcFraction returns Q(a, x) by its continued fraction, evaluated with
+// the modified Lentz method.
+func igamcFraction(a, x float64) float64 {
+	lg, _ := math.Lgamma(a)
+	b := x + 1 - a
+	c := 1 / gammaTiny
+	d := 1 / b
+	h := d
+	for n := 1; n < gammaIterations; n++ {
+		an := -float64(n) * (float64(n) - a)
+		b += 2
+		d = an*d + b
+		if math.Abs(d) < gammaTiny {
+			d = gammaTiny
+		}
+		c = b + an/c
+		if math.Abs(c) < gammaTiny {
+			c = gammaTiny
+		}
+		d = 1 / d
+		delta := d * c
+		h *= delta
+		if math.Abs(delta-1) < gammaEpsilon {
+			break
+		}
+	}
+	return math.Exp(-x+a*math.Log(x)-lg) * h
+}
+
+// normal returns the standard normal cumulative distribution function.
+func normal(x float64) float64 {
+	return 0.5 * math.Erfc(-x/math.Sqrt2)
+}
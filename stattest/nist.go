@@ -0,0 +1,260 @@
+package stattest
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/revision-3/randomness"
+)
+
+// The tests in this file follow NIST SP 800-22 Rev. 1a, "A Statistical Test
+// Suite for Random and Pseudorandom Number Generators for Cryptographic
+// Applications". Each returns the p-value of the test for the bit sequence,
+// or an error if the sequence is too short for it.
+
+// Frequency is the frequency (monobit) test of section 2.1: whether the
+// numbers of ones and zeros are about the same.
+func Frequency(bits randomness.BitArray) (float64, error) {
+	n := len(bits)
+	if n == 0 {
+		return 0, fmt.Errorf("frequency test needs at least one bit")
+	}
+	sum := 0
+	for _, bit := range bits {
+		if bit {
+			sum++
+		} else {
+			sum--
+		}
+	}
+	observed := math.Abs(float64(sum)) / math.Sqrt(float64(n))
+	return math.Erfc(observed / math.Sqrt2), nil
+}
+
+// BlockFrequency is the frequency test within a block of section 2.2:
+// whether the proportion of ones in each block of m bits is about 1/2.
+func BlockFrequency(bits randomness.BitArray, m int) (float64, error) {
+	if m <= 0 {
+		return 0, fmt.Errorf("block length must be positive")
+	}
+	blocks := len(bits) / m
+	if blocks == 0 {
+		return 0, fmt.Errorf("block frequency test needs at least %d bits", m)
+	}
+	chi := 0.0
+	for i := range blocks {
+		ones := 0
+		for _, bit := range bits[i*m : (i+1)*m] {
+			if bit {
+				ones++
+			}
+		}
+		pi := float64(ones)/float64(m) - 0.5
+		chi += pi * pi
+	}
+	chi *= 4 * float64(m)
+	return igamc(float64(blocks)/2, chi/2), nil
+}
+
+// Runs is the runs test of section 2.3: whether the number of runs of
+// identical bits is as expected. It returns 0 if the frequency test would
+// already fail, as the test is then not applicable.
+func Runs(bits randomness.BitArray) (float64, error) {
+	n := len(bits)
+	if n == 0 {
+		return 0, fmt.Errorf("runs test needs at least one bit")
+	}
+	ones := 0
+	for _, bit := range bits {
+		if bit {
+			ones++
+		}
+	}
+	pi := float64(ones) / float64(n)
+	if math.Abs(pi-0.5) >= 2/math.Sqrt(float64(n)) {
+		return 0, nil
+	}
+	runs := 1
+	for i := 1; i < n; i++ {
+		if bits[i] != bits[i-1] {
+			runs++
+		}
+	}
+	expected := 2 * float64(n) * pi * (1 - pi)
+	return math.Erfc(math.Abs(float64(runs)-expected) / (2 * math.Sqrt(2*float64(n)) * pi * (1 - pi))), nil
+}
+
+// longestRunClass holds the parameters of the longest run test for sequences
+// of at least minBits bits: the block length, the longest runs counted in
+// the first and last classes, and the probability of each class.
+type longestRunClass struct {
+	minBits int
+	block   int
+	low     int
+	probs   []float64
+}
+
+var longestRunClasses = []longestRunClass{
+	{750000, 10000, 10, []float64{0.0882, 0.2092, 0.2483, 0.1933, 0.1208, 0.0675, 0.0727}},
+	{6272, 128, 4, []float64{0.1174, 0.2430, 0.2493, 0.1752, 0.1027, 0.1124}},
+	{128, 8, 1, []float64{0.2148, 0.3672, 0.2305, 0.1875}},
+}
+
+// LongestRun is the test for the longest run of ones in a block of section
+// 2.4. The block length is 8, 128 or 10000 bits depending on the length of the
+// sequence, which must be at least 128 bits.
+func LongestRun(bits randomness.BitArray) (float64, error) {
+	n := len(bits)
+	var class longestRunClass
+	for _, class = range longestRunClasses {
+		if n >= class.minBits {
+			break
+		}
+	}
+	if n < class.minBits {
+		return 0, fmt.Errorf("longest run test needs at least %d bits", class.minBits)
+	}
+
+	k := len(class.probs) - 1
+	counts := make([]int, len(class.probs))
+	blocks := n / class.block
+	for i := range blocks {
+		longest, run := 0, 0
+		for _, bit := range bits[i*class.block : (i+1)*class.block] {
+			if bit {
+				run++
+				longest = max(longest, run)
+			} else {
+				run = 0
+			}
+		}
+		counts[min(max(longest-class.low, 0), k)]++
+	}
+
+	chi := 0.0
+	for i, count := range counts {
+		expected := float64(blocks) * class.probs[i]
+		chi += (float64(count) - expected) * (float64(count) - expected) / expected
+	}
+	return igamc(float64(k)/2, chi/2), nil
+}
+
+// psiSquared returns the ψ²ₘ statistic of the serial test: the squared
+// deviation of the counts of every overlapping m-bit pattern, with the
+// sequence wrapped around at the end.
+func psiSquared(bits randomness.BitArray, m int) float64 {
+	if m <= 0 {
+		return 0
+	}
+	n := len(bits)
+	counts := patternCounts(bits, m)
+	sum := 0.0
+	for _, count := range counts {
+		sum += float64(count) * float64(count)
+	}
+	return sum*float64(len(counts))/float64(n) - float64(n)
+}
+
+// patternCounts counts every overlapping m-bit pattern of the sequence,
+// wrapping around at the end, indexed by the pattern read most significant
+// bit first.
+func patternCounts(bits randomness.BitArray, m int) []int {
+	n := len(bits)
+	counts := make([]int, 1<<m)
+	for i := range n {
+		pattern := 0
+		for j := range m {
+			pattern <<= 1
+			if bits[(i+j)%n] {
+				pattern |= 1
+			}
+		}
+		counts[pattern]++
+	}
+	return counts
+}
+
+// Serial is the serial test of section 2.11: whether every overlapping m-bit
+// pattern occurs about as often. It returns the two p-values of the test.
+func Serial(bits randomness.BitArray, m int) (float64, float64, error) {
+	if m < 2 {
+		return 0, 0, fmt.Errorf("serial test pattern length must be at least 2")
+	}
+	if len(bits) < m {
+		return 0, 0, fmt.Errorf("serial test needs at least %d bits", m)
+	}
+	psi0 := psiSquared(bits, m)
+	psi1 := psiSquared(bits, m-1)
+	psi2 := psiSquared(bits, m-2)
+	delta1 := psi0 - psi1
+	delta2 := psi0 - 2*psi1 + psi2
+	return igamc(math.Exp2(float64(m-2)), delta1/2), igamc(math.Exp2(float64(m-3)), delta2/2), nil
+}
+
+// ApproximateEntropy is the approximate entropy test of section 2.12:
+// whether overlapping patterns of m and m+1 bits occur with the frequencies
+// expected of a random sequence.
+func ApproximateEntropy(bits randomness.BitArray, m int) (float64, error) {
+	if m < 1 {
+		return 0, fmt.Errorf("approximate entropy pattern length must be positive")
+	}
+	n := len(bits)
+	if n <= m {
+		return 0, fmt.Errorf("approximate entropy test needs more than %d bits", m)
+	}
+	phi := func(m int) float64 {
+		sum := 0.0
+		for _, count := range patternCounts(bits, m) {
+			if count > 0 {
+				p := float64(count) / float64(n)
+				sum += p * math.Log(p)
+			}
+		}
+		return sum
+	}
+	apen := phi(m) - phi(m+1)
+	chi := 2 * float64(n) * (math.Ln2 - apen)
+	return igamc(math.Exp2(float64(m-1)), chi/2), nil
+}
+
+// CumulativeSums is the cumulative sums test of section 2.13: whether the
+// random walk of ±1 steps strays too far from zero. It returns the p-values
+// of the forward and backward walks.
+func CumulativeSums(bits randomness.BitArray) (float64, float64, error) {
+	n := len(bits)
+	if n == 0 {
+		return 0, 0, fmt.Errorf("cumulative sums test needs at least one bit")
+	}
+	walk := func(forward bool) int {
+		sum, z := 0, 0
+		for i := range n {
+			bit := bits[i]
+			if !forward {
+				bit = bits[n-1-i]
+			}
+			if bit {
+				sum++
+			} else {
+				sum--
+			}
+			z = max(z, sum, -sum)
+		}
+		return z
+	}
+	return cusumP(n, walk(true)), cusumP(n, walk(false)), nil
+}
+
+// cusumP returns the p-value of the maximum excursion z of a walk of n steps.
+func cusumP(n, z int) float64 {
+	rootN := math.Sqrt(float64(n))
+	fz := float64(z)
+	sum1 := 0.0
+	for k := (-n/z + 1) / 4; k <= (n/z-1)/4; k++ {
+		sum1 += normal(float64(4*k+1)*fz/rootN) - normal(float64(4*k-1)*fz/rootN)
+	}
+	sum2 := 0.0
+	for k := (-n/z - 3) / 4; k <= (n/z-1)/4; k++ {
+		sum2 += normal(float64(4*k+3)*fz/rootN) - normal(float64(4*k+1)*fz/rootN)
+	}
+	return 1 - sum1 + sum2
+}
@@ -0,0 +1,161 @@
+// Package stattest runs statistical tests of randomness against the output of
+// randomness.Randomness: a subset of the NIST SP 800-22 suite over its bits,
+// and chi-square goodness-of-fit tests of Pick and Selection.
+package stattest
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/revision-3/randomness"
+)
+
+// DefaultAlpha is the significance level of NIST SP 800-22: a test fails if
+// its p-value is below it.
+const DefaultAlpha = 0.01
+
+// Result is the outcome of a single test.
+type Result struct {
+	Name    string
+	PValues []float64
+	Pass    bool
+}
+
+// Report is the outcome of a set of tests.
+type Report struct {
+	Alpha   float64
+	Results []Result
+}
+
+// Config configures Run. Zero values take the defaults.
+type Config struct {
+	// Bits is the length of the bit sequence tested. It defaults to 1000000.
+	Bits int
+
+	// Alpha is the significance level. It defaults to DefaultAlpha.
+	Alpha float64
+
+	// BlockLength is the block length of the block frequency test. It
+	// defaults to 128.
+	BlockLength int
+
+	// SerialLength is the pattern length of the serial test. It defaults
+	// to 16.
+	SerialLength int
+
+	// EntropyLength is the pattern length of the approximate entropy test.
+	// It defaults to 10.
+	EntropyLength int
+}
+
+func (c *Config) defaults() {
+	if c.Bits == 0 {
+		c.Bits = 1000000
+	}
+	if c.Alpha == 0 {
+		c.Alpha = DefaultAlpha
+	}
+	if c.BlockLength == 0 {
+		c.BlockLength = 128
+	}
+	if c.SerialLength == 0 {
+		c.SerialLength = 16
+	}
+	if c.EntropyLength == 0 {
+		c.EntropyLength = 10
+	}
+}
+
+// Run reads cfg.Bits bits from r and runs every NIST test in this package
+// on them.
+func Run(r randomness.Randomness, cfg Config) (*Report, error) {
+	cfg.defaults()
+	bits, err := r.Bits(cfg.Bits)
+	if err != nil {
+		return nil, err
+	}
+	bits = bits[:cfg.Bits]
+
+	report := &Report{Alpha: cfg.Alpha}
+	add := func(name string, err error, pvalues ...float64) error {
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		report.Add(name, pvalues...)
+		return nil
+	}
+
+	p, err := Frequency(bits)
+	if err := add("frequency", err, p); err != nil {
+		return nil, err
+	}
+	p, err = BlockFrequency(bits, cfg.BlockLength)
+	if err := add("block frequency", err, p); err != nil {
+		return nil, err
+	}
+	p, err = Runs(bits)
+	if err := add("runs", err, p); err != nil {
+		return nil, err
+	}
+	p, err = LongestRun(bits)
+	if err := add("longest run", err, p); err != nil {
+		return nil, err
+	}
+	p1, p2, err := Serial(bits, cfg.SerialLength)
+	if err := add("serial", err, p1, p2); err != nil {
+		return nil, err
+	}
+	p, err = ApproximateEntropy(bits, cfg.EntropyLength)
+	if err := add("approximate entropy", err, p); err != nil {
+		return nil, err
+	}
+	p1, p2, err = CumulativeSums(bits)
+	if err := add("cumulative sums", err, p1, p2); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// Add records the p-values of a test, which passes if none is below the
+// significance level of the report.
+func (r *Report) Add(name string, pvalues ...float64) {
+	alpha := r.Alpha
+	if alpha == 0 {
+		alpha = DefaultAlpha
+	}
+	pass := true
+	for _, p := range pvalues {
+		if math.IsNaN(p) || p < alpha {
+			pass = false
+		}
+	}
+	r.Results = append(r.Results, Result{Name: name, PValues: pvalues, Pass: pass})
+}
+
+// Passed reports whether every test passed.
+func (r *Report) Passed() bool {
+	for _, result := range r.Results {
+		if !result.Pass {
+			return false
+		}
+	}
+	return true
+}
+
+// String formats the report for display.
+func (r *Report) String() string {
+	var b strings.Builder
+	for _, result := range r.Results {
+		verdict := "PASS"
+		if !result.Pass {
+			verdict = "FAIL"
+		}
+		fmt.Fprintf(&b, "%-24s %s", result.Name, verdict)
+		for _, p := range result.PValues {
+			fmt.Fprintf(&b, "  p=%.6f", p)
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
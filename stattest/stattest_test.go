@@ -0,0 +1,140 @@
+package stattest
+
+import (
+	"math"
+	"testing"
+
+	"github.com/revision-3/randomness"
+)
+
+// parseBits converts a string of '0' and '1' to a bit array.
+func parseBits(s string) randomness.BitArray {
+	bits := make(randomness.BitArray, len(s))
+	for i, c := range s {
+		bits[i] = c == '1'
+	}
+	return bits
+}
+
+// The examples of NIST SP 800-22 Rev. 1a.
+func TestNISTExamples(t *testing.T) {
+	check := func(name string, got, want float64) {
+		t.Helper()
+		if math.Abs(got-want) > 1e-6 {
+			t.Errorf("%s = %.7f, want %.7f", name, got, want)
+		}
+	}
+
+	p, err := Frequency(parseBits("1011010101"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("Frequency()", p, 0.527089)
+
+	p, err = BlockFrequency(parseBits("0110011010"), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("BlockFrequency()", p, 0.801252)
+
+	p, err = Runs(parseBits("1001101011"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("Runs()", p, 0.147232)
+
+	p, err = LongestRun(parseBits("11001100000101010110110001001100111000000000001001001101010100010001001111010110100000001101011111001100111001101101100010110010"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(p-0.180609) > 1e-4 {
+		t.Errorf("LongestRun() = %.6f, want 0.180609", p)
+	}
+
+	p1, p2, err := Serial(parseBits("0011011101"), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("Serial() p1", p1, 0.808792)
+	check("Serial() p2", p2, 0.670320)
+
+	p, err = ApproximateEntropy(parseBits("0100110101"), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("ApproximateEntropy()", p, 0.261961)
+
+	forward, _, err := CumulativeSums(parseBits("1011010111"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("CumulativeSums()", forward, 0.4116588)
+}
+
+func TestIgamc(t *testing.T) {
+	// Q(1, x) = e^-x and Q(a, 0) = 1.
+	for _, x := range []float64{0.1, 1, 5, 30} {
+		if got := igamc(1, x); math.Abs(got-math.Exp(-x)) > 1e-12 {
+			t.Errorf("igamc(1, %g) = %g, want %g", x, got, math.Exp(-x))
+		}
+	}
+	if got := igamc(3, 0); got != 1 {
+		t.Errorf("igamc(3, 0) = %g, want 1", got)
+	}
+}
+
+func TestRun(t *testing.T) {
+	r := randomness.NewRandomness(randomness.BetaBytes("stattest"))
+	report, err := Run(r, Config{Bits: 100000})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(report.Results) != 7 {
+		t.Errorf("Run() ran %d tests, want 7", len(report.Results))
+	}
+	if !report.Passed() {
+		t.Errorf("Run() failed:\n%s", report)
+	}
+
+	// A constant stream fails.
+	constant := make(randomness.BitArray, 1000)
+	p, err := Frequency(constant)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bad := &Report{}
+	bad.Add("frequency", p)
+	if bad.Passed() {
+		t.Error("Report.Passed() = true for a constant stream")
+	}
+}
+
+func TestChiSquare(t *testing.T) {
+	r := randomness.NewRandomness(randomness.BetaBytes("stattest"))
+	p, err := ChiSquarePick(r, 37000, 37)
+	if err != nil {
+		t.Fatalf("ChiSquarePick() error = %v", err)
+	}
+	if p < DefaultAlpha {
+		t.Errorf("ChiSquarePick() = %f", p)
+	}
+
+	items := []randomness.Item{
+		randomness.NewGenericItem("common", 6, 3),
+		randomness.NewGenericItem("rare", 1, 2),
+		randomness.NewGenericItem("endless", 2, -1),
+	}
+	pvalues, err := ChiSquareSelection(r, randomness.SelectionConfig{Items: items, Count: 3}, 5000)
+	if err != nil {
+		t.Fatalf("ChiSquareSelection() error = %v", err)
+	}
+	for d, p := range pvalues {
+		if p < DefaultAlpha {
+			t.Errorf("ChiSquareSelection()[%d] = %f", d, p)
+		}
+	}
+
+	if p, _ := ChiSquare([]int{100, 0}, []float64{50, 50}); p > 1e-10 {
+		t.Errorf("ChiSquare() = %g for a lopsided count", p)
+	}
+}
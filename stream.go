@@ -0,0 +1,85 @@
+package randomness
+
+import "fmt"
+
+// Stream selects how the cursor advances through the stream.
+type Stream int
+
+const (
+	// StreamBytes consumes whole bytes: Bits(n) consumes ceil(n/8) bytes
+	// and Numbers rounds the bits it needs up to whole bytes. It is the
+	// behaviour of every algorithm version.
+	StreamBytes Stream = iota
+
+	// StreamBits advances the cursor bit by bit, so Bits(3) followed by
+	// Bits(5) consumes a single byte and Numbers consumes exactly the bits
+	// it uses. Byte reads such as Uint64 consume the next 8*n bits even
+	// when the cursor is not byte-aligned.
+	StreamBits
+)
+
+func (s Stream) String() string {
+	switch s {
+	case StreamBytes:
+		return "bytes"
+	case StreamBits:
+		return "bits"
+	default:
+		return fmt.Sprintf("Stream(%d)", int(s))
+	}
+}
+
+// ParseStream parses a stream in the form returned by Stream.String, e.g.
+// "bits".
+func ParseStream(s string) (Stream, error) {
+	for _, stream := range []Stream{StreamBytes, StreamBits} {
+		if stream.String() == s {
+			return stream, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown stream %q", s)
+}
+
+// WithStream selects how the cursor advances through the stream, overriding
+// the default of the algorithm version.
+func WithStream(s Stream) Option {
+	return func(b *randomness) {
+		b.stream = s
+	}
+}
+
+// getUnaligned returns the next n bytes of the stream when the cursor is
+// part way into the byte at pos. The slice is only valid until the next call
+// to get.
+func (b *randomness) getUnaligned(n int) ([]byte, error) {
+	if err := b.need(n + 1); err != nil {
+		return nil, err
+	}
+	src := b.window(b.pos, b.pos+n+1)
+	b.scratch = b.scratch[:0]
+	for i := range n {
+		b.scratch = append(b.scratch, src[i]<<b.bit|src[i+1]>>(8-b.bit))
+	}
+	b.pos += n
+	return b.scratch, nil
+}
+
+// getBits returns the next n bits of the stream, most significant bit of
+// each byte first, advancing the cursor by exactly n bits.
+func (b *randomness) getBits(n int) (BitArray, error) {
+	bits := make(BitArray, 0, n)
+	for range n {
+		if b.bit == 0 {
+			if err := b.need(1); err != nil {
+				return nil, err
+			}
+		}
+		bits = append(bits, b.window(b.pos, b.pos+1)[0]&(1<<(7-b.bit)) != 0)
+		b.bit++
+		if b.bit == 8 {
+			b.pos++
+			b.bit = 0
+		}
+	}
+	return bits, nil
+}
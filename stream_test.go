@@ -0,0 +1,140 @@
+package randomness
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func TestStreamBits(t *testing.T) {
+	beta := BetaValues(uint8(0b10110011), uint8(0b01011100), uint8(0xff))
+
+	r := NewRandomness(beta, WithStream(StreamBits))
+	three, _ := r.Bits(3)
+	five, _ := r.Bits(5)
+	got := append(three, five...)
+	for i, want := range []bool{true, false, true, true, false, false, true, true} {
+		if got[i] != want {
+			t.Errorf("bit %d = %v, want %v", i, got[i], want)
+		}
+	}
+	if u, _ := r.Uint8(); u != 0b01011100 {
+		t.Errorf("Uint8() after Bits(3) and Bits(5) = %08b, want %08b", u, 0b01011100)
+	}
+
+	// Byte reads continue from an unaligned cursor.
+	r = NewRandomness(beta, WithStream(StreamBits))
+	r.Bits(4)
+	if u, _ := r.Uint16(); u != 0b0011010111001111 {
+		t.Errorf("Uint16() after Bits(4) = %016b, want %016b", u, 0b0011010111001111)
+	}
+
+	// The byte stream rounds up to whole bytes.
+	r = NewRandomness(beta)
+	r.Bits(3)
+	if u, _ := r.Uint8(); u != 0b01011100 {
+		t.Errorf("Uint8() after Bits(3) = %08b, want %08b", u, 0b01011100)
+	}
+}
+
+func TestStreamBitsNumbers(t *testing.T) {
+	// Pick(2, 8) uses 3 bits per number plus the priming number: 9 bits.
+	r := NewRandomness(BetaValues(uint16(0xabcd), uint8(0x12)), WithStream(StreamBits))
+	if _, err := r.Pick(2, 8); err != nil {
+		t.Fatalf("Pick() error = %v", err)
+	}
+	b := r.(*randomness)
+	if b.pos != 1 || b.bit != 1 {
+		t.Errorf("cursor after Pick(2, 8) = byte %d bit %d, want byte 1 bit 1", b.pos, b.bit)
+	}
+
+	// The same bits give the same numbers as the byte stream.
+	bits, _ := NewRandomness(BetaValues(uint16(0xabcd))).Pick(2, 8)
+	again, _ := NewRandomness(BetaValues(uint16(0xabcd)), WithStream(StreamBits)).Pick(2, 8)
+	for i := range bits {
+		if bits[i] != again[i] {
+			t.Errorf("Pick()[%d] = %d, want %d", i, again[i], bits[i])
+		}
+	}
+	// The bit stream holds exactly the numbers asked for, so reading one
+	// more is an error rather than a read past the bits.
+	numbers, err := NewRandomness(BetaBytes("test"), WithStream(StreamBits)).Numbers(2, 8)
+	if err != nil {
+		t.Fatalf("Numbers() error = %v", err)
+	}
+	if _, err := numbers.Readn(2, 8); err != nil {
+		t.Fatalf("Readn() error = %v", err)
+	}
+	if _, err := numbers.Read(8); err == nil {
+		t.Error("Read() past the bits did not return an error")
+	}
+}
+
+func TestStreamBitsTranscript(t *testing.T) {
+	var transcript Transcript
+	r := NewRandomness(BetaBytes("test"), WithStream(StreamBits), WithTranscript(&transcript))
+	r.Bits(3)
+	r.Uint8()
+	r.Bits(13)
+
+	expected := []TranscriptEntry{
+		{Method: "Bits", Offset: 0, Length: 1, BitOffset: 0, BitLength: 3},
+		{Method: "Uint8", Offset: 0, Length: 2, BitOffset: 3, BitLength: 8},
+		{Method: "Bits", Offset: 1, Length: 2, BitOffset: 3, BitLength: 13},
+	}
+	for i, want := range expected {
+		got := transcript.Entries[i]
+		if got.Method != want.Method || got.Offset != want.Offset || got.Length != want.Length ||
+			got.BitOffset != want.BitOffset || got.BitLength != want.BitLength {
+			t.Errorf("entry %d = %+v, want %+v", i, got, want)
+		}
+	}
+	if transcript.Stream != "bits" {
+		t.Errorf("Stream = %q, want bits", transcript.Stream)
+	}
+	if err := transcript.Verify(); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+}
+
+func TestStreamBitsState(t *testing.T) {
+	r := NewRandomness(BetaBytes("test"), WithStream(StreamBits))
+	control := NewRandomness(BetaBytes("test"), WithStream(StreamBits))
+	r.Bits(1000)
+	control.Bits(1000)
+	r.Bits(5)
+	control.Bits(5)
+
+	state, _ := r.MarshalBinary()
+	restored, err := RestoreRandomness(state)
+	if err != nil {
+		t.Fatalf("RestoreRandomness() error = %v", err)
+	}
+	for range 10 {
+		want, _ := control.Uint64()
+		if got, _ := restored.Uint64(); got != want {
+			t.Errorf("Uint64() after restore = %d, want %d", got, want)
+		}
+	}
+}
+
+func TestRestoreStateFormat1(t *testing.T) {
+	state := []byte{1, byte(AlgorithmV2), byte(SamplingRejection)}
+	state = append(state, byte(len("sha512")))
+	state = append(state, "sha512"...)
+	state = append(state, 4)
+	state = append(state, "test"...)
+	state = binary.AppendUvarint(state, 100)
+	state = binary.AppendUvarint(state, 2)
+
+	restored, err := RestoreRandomness(state)
+	if err != nil {
+		t.Fatalf("RestoreRandomness() error = %v", err)
+	}
+
+	control, _ := NewRandomnessV(AlgorithmV2, BetaBytes("test"))
+	control.Bytes(100)
+	want, _ := control.Uint64()
+	if got, _ := restored.Uint64(); got != want {
+		t.Errorf("Uint64() after restore = %d, want %d", got, want)
+	}
+}
@@ -0,0 +1,155 @@
+package randomness
+
+import (
+	"fmt"
+	"math"
+)
+
+// WeightedTable is a precompiled table for repeated draws from items that all
+// have an infinite supply, such as slot reels and loot tables. It is built
+// once with Vose's alias method and each draw then takes O(1) time. As with
+// Selection, the relative weight of an item is its weight multiplied by the
+// magnitude of its supply.
+//
+// A draw consumes the stream as follows: a column is chosen uniformly from
+// [0, n) with the rejection sampling of SamplingRejection, then one Uint64 is
+// read as a coin. If the coin is below the threshold of the column its item
+// is drawn, otherwise the item of its alias is.
+type WeightedTable struct {
+	items     []Item
+	threshold []uint64 // Coins below the threshold keep the column
+	keep      []bool   // The column is always kept, its threshold would be 2^64
+	alias     []int
+}
+
+// NewWeightedTable builds a WeightedTable from items with infinite supply.
+//
+// Each column starts with the probability of its item scaled by n and is put
+// on the small (below 1) or large work list in index order. The head of the
+// small list is repeatedly paired with the head of the large list: its
+// remainder is aliased to the large column, which moves to the tail of the
+// list it then belongs on. Thresholds are the scaled probability
+// multiplied by 2^64 and truncated, so Probability reports the exact odds of
+// the built table.
+func NewWeightedTable(items []Item) (*WeightedTable, error) {
+	if len(items) == 0 {
+		return nil, fmt.Errorf("no items to select from")
+	}
+	weights := make([]float64, len(items))
+	total := 0.0
+	for i, item := range items {
+		if item.Supply() >= 0 {
+			return nil, fmt.Errorf("item %d has a finite supply of %d, weighted tables need infinite supplies", i, item.Supply())
+		}
+		w := item.Weight()
+		if w < 0 || math.IsNaN(w) || math.IsInf(w, 0) {
+			return nil, fmt.Errorf("weights must be non-negative and finite")
+		}
+		weights[i] = w * float64(-item.Supply())
+		total += weights[i]
+	}
+	if total == 0 || math.IsInf(total, 0) {
+		return nil, fmt.Errorf("total weight must be positive and finite")
+	}
+
+	n := len(items)
+	t := &WeightedTable{
+		items:     append([]Item(nil), items...),
+		threshold: make([]uint64, n),
+		keep:      make([]bool, n),
+		alias:     make([]int, n),
+	}
+
+	scaled := make([]float64, n)
+	var small, large []int
+	for i, w := range weights {
+		scaled[i] = w / total * float64(n)
+		if scaled[i] < 1 {
+			small = append(small, i)
+		} else {
+			large = append(large, i)
+		}
+	}
+	for len(small) > 0 && len(large) > 0 {
+		s, l := small[0], large[0]
+		small, large = small[1:], large[1:]
+		t.setColumn(s, scaled[s], l)
+		scaled[l] = scaled[l] + scaled[s] - 1
+		if scaled[l] < 1 {
+			small = append(small, l)
+		} else {
+			large = append(large, l)
+		}
+	}
+	// Whatever remains is 1 up to rounding.
+	for _, i := range append(small, large...) {
+		t.keep[i] = true
+		t.alias[i] = i
+	}
+	return t, nil
+}
+
+// setColumn keeps column i with probability p and otherwise aliases it.
+func (t *WeightedTable) setColumn(i int, p float64, alias int) {
+	t.alias[i] = alias
+	if p <= 0 {
+		return
+	}
+	t.threshold[i] = uint64(math.Ldexp(p, 64))
+}
+
+// Len returns the number of items in the table.
+func (t *WeightedTable) Len() int {
+	return len(t.items)
+}
+
+// Item returns item i of the table.
+func (t *WeightedTable) Item(i int) Item {
+	return t.items[i]
+}
+
+// DrawIndex draws the index of an item from the table.
+func (t *WeightedTable) DrawIndex(r Randomness) (int, error) {
+	column, err := uniform(r, uint64(len(t.items)))
+	if err != nil {
+		return 0, err
+	}
+	coin, err := r.Uint64()
+	if err != nil {
+		return 0, err
+	}
+	if t.keep[column] || coin < t.threshold[column] {
+		return int(column), nil
+	}
+	return t.alias[column], nil
+}
+
+// Draw draws an item from the table.
+func (t *WeightedTable) Draw(r Randomness) (Item, error) {
+	i, err := t.DrawIndex(r)
+	if err != nil {
+		return nil, err
+	}
+	return t.items[i], nil
+}
+
+// Probability returns the exact probability that a draw returns item i, as
+// built into the table, which may differ from the ideal weight ratio by
+// floating point rounding.
+func (t *WeightedTable) Probability(i int) float64 {
+	n := float64(len(t.items))
+	p := 0.0
+	for column := range t.items {
+		kept := 1.0
+		if !t.keep[column] {
+			kept = math.Ldexp(float64(t.threshold[column]), -64)
+		}
+		if column == i {
+			p += kept
+		}
+		if t.alias[column] == i && column != i {
+			p += 1 - kept
+		}
+	}
+	return p / n
+}
@@ -0,0 +1,115 @@
+package randomness
+
+import (
+	"math"
+	"testing"
+)
+
+func TestWeightedTableProbability(t *testing.T) {
+	items := []Item{
+		&testItem{value: 1, weight: 1.0, supply: -1},
+		&testItem{value: 2, weight: 2.0, supply: -1},
+		&testItem{value: 3, weight: 0.5, supply: -4},
+		&testItem{value: 4, weight: 0, supply: -1},
+		&testItem{value: 5, weight: 3.0, supply: -1},
+	}
+	table, err := NewWeightedTable(items)
+	if err != nil {
+		t.Fatalf("NewWeightedTable() error = %v", err)
+	}
+
+	expected := []float64{1.0 / 8, 2.0 / 8, 2.0 / 8, 0, 3.0 / 8}
+	total := 0.0
+	for i, want := range expected {
+		got := table.Probability(i)
+		if math.Abs(got-want) > 1e-12 {
+			t.Errorf("Probability(%d) = %v, want %v", i, got, want)
+		}
+		total += got
+	}
+	if math.Abs(total-1) > 1e-12 {
+		t.Errorf("probabilities sum to %v", total)
+	}
+}
+
+func TestWeightedTableDistribution(t *testing.T) {
+	items := []Item{
+		&testItem{value: 1, weight: 1.0, supply: -1},
+		&testItem{value: 2, weight: 2.0, supply: -1},
+		&testItem{value: 3, weight: 7.0, supply: -1},
+	}
+	table, err := NewWeightedTable(items)
+	if err != nil {
+		t.Fatalf("NewWeightedTable() error = %v", err)
+	}
+
+	iterations := 100000
+	counts := make(map[int]int)
+	r := NewRandomness(BetaValues(GenerateTestRandomValue()))
+	for range iterations {
+		item, err := table.Draw(r)
+		if err != nil {
+			t.Fatalf("Draw() error = %v", err)
+		}
+		counts[item.(*testItem).value]++
+	}
+
+	for value, weight := range map[int]float64{1: 0.1, 2: 0.2, 3: 0.7} {
+		expected := float64(iterations) * weight
+		if math.Abs(float64(counts[value])-expected)/expected > 0.05 {
+			t.Errorf("Value %d: count = %d, expected ≈ %.0f", value, counts[value], expected)
+		}
+	}
+}
+
+func TestWeightedTableConsumption(t *testing.T) {
+	table, _ := NewWeightedTable([]Item{
+		&testItem{value: 1, weight: 1.0, supply: -1},
+		&testItem{value: 2, weight: 3.0, supply: -1},
+	})
+
+	// Column 0 has probability 0.5 scaled to 2^63 and is aliased to item 1.
+	tests := []struct {
+		column, coin uint64
+		want         int
+	}{
+		{0, 0, 0},
+		{0, 1<<63 - 1, 0},
+		{0, 1 << 63, 1},
+		{math.MaxUint64, 0, 1},
+	}
+	for _, tt := range tests {
+		var transcript Transcript
+		r := NewRandomness(BetaValues(tt.column, tt.coin), WithTranscript(&transcript))
+		got, err := table.DrawIndex(r)
+		if err != nil {
+			t.Fatalf("DrawIndex() error = %v", err)
+		}
+		if got != tt.want {
+			t.Errorf("DrawIndex() with column %#x coin %#x = %d, want %d", tt.column, tt.coin, got, tt.want)
+		}
+		if len(transcript.Entries) != 2 || transcript.Entries[1].Offset != 8 {
+			t.Errorf("DrawIndex() consumed %+v, want two Uint64 reads", transcript.Entries)
+		}
+	}
+}
+
+func TestNewWeightedTableErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		items []Item
+	}{
+		{"empty", nil},
+		{"finite supply", []Item{&testItem{weight: 1, supply: 1}}},
+		{"negative weight", []Item{&testItem{weight: -1, supply: -1}}},
+		{"NaN weight", []Item{&testItem{weight: math.NaN(), supply: -1}}},
+		{"zero total", []Item{&testItem{weight: 0, supply: -1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewWeightedTable(tt.items); err == nil {
+				t.Error("NewWeightedTable() did not return an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,213 @@
+package randomness
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// Transcript is an audit log of every call that consumed bytes from a
+// Randomness. It records enough to recreate the stream, so a verifier can
+// check each entry against the beta and diff a replayed round call-by-call.
+type Transcript struct {
+	Beta      BetaBytes         `json:"beta"`
+	Algorithm string            `json:"algorithm"`
+	Amplifier string            `json:"amplifier"`
+	Sampling  string            `json:"sampling"`
+	Stream    string            `json:"stream"`
+	Entries   []TranscriptEntry `json:"entries"`
+}
+
+// TranscriptEntry records the bytes consumed by a single call. Calls made by
+// another method, e.g. the Probability calls made by Selection, are folded
+// into the entry of the outermost call.
+//
+// Offset, Length and Bytes cover every byte the call touched. With
+// StreamBits a call may start or end part way into a byte, so BitOffset is
+// the number of bits of the first byte consumed before the call and BitLength
+// the exact number of bits it consumed.
+//
+// Value is the JSON encoding of the value the call returned, taken as the
+// call returns so that later changes to the value, such as shuffling the
+// same slice again, do not alter the entry. It is null if the call returned
+// no value or the value cannot be encoded.
+type TranscriptEntry struct {
+	Method    string          `json:"method"`
+	Offset    int             `json:"offset"` // Offset of the first byte in the stream
+	Length    int             `json:"length"`
+	BitOffset int             `json:"bitOffset,omitempty"`
+	BitLength int             `json:"bitLength,omitempty"`
+	Bytes     BetaBytes       `json:"bytes"`
+	Value     json.RawMessage `json:"value"` // The JSON encoding of the value returned by the call
+}
+
+// WithTranscript records every call on the Randomness into t.
+func WithTranscript(t *Transcript) Option {
+	return func(b *randomness) {
+		b.transcript = t
+	}
+}
+
+// begin fills in the header of the transcript from b.
+func (t *Transcript) begin(b *randomness) {
+	t.Beta = append(BetaBytes(nil), b.beta...)
+	t.Algorithm = b.algorithm.String()
+	t.Amplifier = b.amplifier.ID()
+	t.Sampling = b.sampling.String()
+	t.Stream = b.stream.String()
+}
+
+var recordNothing = func() {}
+
+// record starts a transcript entry for a call to method and returns a
+// function that completes it. value points at the result of the call and is
+// encoded on completion; it may be nil.
+func (b *randomness) record(method string, value any) func() {
+	if b.transcript == nil {
+		return recordNothing
+	}
+	b.recording++
+	if b.recording > 1 {
+		return func() { b.recording-- }
+	}
+	start, startBit := b.pos, b.bit
+	return func() {
+		b.recording--
+		end := b.pos
+		if b.bit != 0 {
+			end++
+		}
+		entry := TranscriptEntry{
+			Method: method,
+			Offset: start,
+			Length: end - start,
+			Bytes:  append(BetaBytes(nil), b.window(start, end)...),
+		}
+		if b.stream == StreamBits {
+			entry.BitOffset = int(startBit)
+			entry.BitLength = (b.pos-start)*8 + int(b.bit) - int(startBit)
+		}
+		if value != nil {
+			entry.Value, _ = json.Marshal(reflect.ValueOf(value).Elem().Interface())
+		}
+		b.transcript.Entries = append(b.transcript.Entries, entry)
+	}
+}
+
+// Verify recreates the stream from the header of the transcript and checks
+// that every entry consumed the bytes found at its offset. A transcript
+// without a stream, recorded before streams could be selected, is verified
+// with the stream of its algorithm version.
+func (t *Transcript) Verify() error {
+	algorithm, err := ParseAlgorithm(t.Algorithm)
+	if err != nil {
+		return err
+	}
+	amplifier, err := AmplifierByID(t.Amplifier)
+	if err != nil {
+		return err
+	}
+	sampling, err := ParseSampling(t.Sampling)
+	if err != nil {
+		return err
+	}
+	opts := []Option{WithAmplifier(amplifier), WithSampling(sampling)}
+	if t.Stream != "" {
+		stream, err := ParseStream(t.Stream)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, WithStream(stream))
+	}
+	b := newRandomness(algorithm, t.Beta, opts...)
+	for i, entry := range t.Entries {
+		if entry.Length != len(entry.Bytes) {
+			return fmt.Errorf("entry %d (%s): length %d does not match %d bytes", i, entry.Method, entry.Length, len(entry.Bytes))
+		}
+		if entry.Offset < 0 {
+			return fmt.Errorf("entry %d (%s): negative offset %d", i, entry.Method, entry.Offset)
+		}
+		if err := b.seek(entry.Offset); err != nil {
+			return fmt.Errorf("entry %d (%s): %w", i, entry.Method, err)
+		}
+		consumed, err := b.get(entry.Length)
+		if err != nil {
+			return fmt.Errorf("entry %d (%s): %w", i, entry.Method, err)
+		}
+		if !bytes.Equal(consumed, entry.Bytes) {
+			return fmt.Errorf("entry %d (%s): bytes at offset %d do not match the stream", i, entry.Method, entry.Offset)
+		}
+	}
+	return nil
+}
+
+// Diff compares two transcripts call-by-call and returns a description of
+// every difference. Values are compared by their JSON encoding with the keys
+// of every object sorted, so a transcript loaded from JSON can be diffed
+// against a recorded one, including transcripts recorded before items were
+// encoded with their keys in a fixed order (see MarshalItems).
+func (t *Transcript) Diff(other *Transcript) []string {
+	var diffs []string
+	header := func(name, a, b string) {
+		if a != b {
+			diffs = append(diffs, fmt.Sprintf("%s: %s != %s", name, a, b))
+		}
+	}
+	header("beta", t.Beta.String(), other.Beta.String())
+	header("algorithm", t.Algorithm, other.Algorithm)
+	header("amplifier", t.Amplifier, other.Amplifier)
+	header("sampling", t.Sampling, other.Sampling)
+	header("stream", t.Stream, other.Stream)
+
+	for i := range max(len(t.Entries), len(other.Entries)) {
+		if i >= len(t.Entries) {
+			diffs = append(diffs, fmt.Sprintf("entry %d: missing, other has %s", i, other.Entries[i].Method))
+			continue
+		}
+		if i >= len(other.Entries) {
+			diffs = append(diffs, fmt.Sprintf("entry %d: %s, missing in other", i, t.Entries[i].Method))
+			continue
+		}
+		a, b := t.Entries[i], other.Entries[i]
+		if a.Method != b.Method {
+			diffs = append(diffs, fmt.Sprintf("entry %d: method %s != %s", i, a.Method, b.Method))
+		}
+		if a.Offset != b.Offset || a.Length != b.Length || a.BitOffset != b.BitOffset || a.BitLength != b.BitLength {
+			diffs = append(diffs, fmt.Sprintf("entry %d (%s): consumed %s != %s", i, a.Method, a.span(), b.span()))
+		}
+		if !bytes.Equal(a.Bytes, b.Bytes) {
+			diffs = append(diffs, fmt.Sprintf("entry %d (%s): bytes %s != %s", i, a.Method, a.Bytes, b.Bytes))
+		}
+		av, aerr := canonicalJSON(a.Value)
+		bv, berr := canonicalJSON(b.Value)
+		if aerr != nil || berr != nil || !bytes.Equal(av, bv) {
+			diffs = append(diffs, fmt.Sprintf("entry %d (%s): value %s != %s", i, a.Method, av, bv))
+		}
+	}
+	return diffs
+}
+
+// canonicalJSON encodes v as JSON with the keys of every object sorted, so
+// values compare equal whatever order their encoders wrote the keys in.
+func canonicalJSON(v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var generic any
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return nil, err
+	}
+	return json.Marshal(generic)
+}
+
+// span describes the part of the stream consumed by the entry, in bits for
+// StreamBits and in bytes otherwise.
+func (e TranscriptEntry) span() string {
+	if e.BitOffset != 0 || e.BitLength != 0 {
+		start := e.Offset*8 + e.BitOffset
+		return fmt.Sprintf("bits [%d, %d)", start, start+e.BitLength)
+	}
+	return fmt.Sprintf("[%d, %d)", e.Offset, e.Offset+e.Length)
+}
@@ -0,0 +1,249 @@
+package randomness
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestTranscript(t *testing.T) {
+	var transcript Transcript
+	r := NewRandomness(BetaBytes("test"), WithTranscript(&transcript))
+
+	if _, err := r.Uint32(); err != nil {
+		t.Fatalf("Uint32() error = %v", err)
+	}
+	if _, err := r.Probability(); err != nil {
+		t.Fatalf("Probability() error = %v", err)
+	}
+	results, err := r.Selection(SelectionConfig{Items: goldenItems(), Count: 2})
+	if err != nil {
+		t.Fatalf("Selection() error = %v", err)
+	}
+
+	if transcript.Beta.String() != BetaBytes("test").String() || transcript.Algorithm != "v1" ||
+		transcript.Amplifier != "sha512" || transcript.Sampling != "accumulator" {
+		t.Errorf("unexpected transcript header %+v", transcript)
+	}
+
+	expected := []struct {
+		method string
+		offset int
+		length int
+	}{
+		{"Uint32", 0, 4},
+		{"Probability", 4, 8},
+		{"Selection", 12, 16}, // Two nested Probability calls
+	}
+	if len(transcript.Entries) != len(expected) {
+		t.Fatalf("got %d entries, want %d", len(transcript.Entries), len(expected))
+	}
+	for i, want := range expected {
+		entry := transcript.Entries[i]
+		if entry.Method != want.method || entry.Offset != want.offset || entry.Length != want.length {
+			t.Errorf("entry %d = %s [%d+%d], want %s [%d+%d]", i, entry.Method, entry.Offset, entry.Length,
+				want.method, want.offset, want.length)
+		}
+	}
+	if v := string(transcript.Entries[0].Value); v != "1952805748" {
+		t.Errorf("entry 0 value = %s, want %d", v, 0x74657374)
+	}
+	var selected []struct {
+		Instance    int         `json:"instance"`
+		Fraction    float64     `json:"fraction"`
+		Explanation Explanation `json:"explanation"`
+	}
+	if err := json.Unmarshal(transcript.Entries[2].Value, &selected); err != nil || len(selected) != len(results) {
+		t.Fatalf("entry 2 value %s has %d results, want %d", transcript.Entries[2].Value, len(selected), len(results))
+	}
+	for i, result := range results {
+		if selected[i].Instance != result.Instance() || selected[i].Fraction != result.Fraction() ||
+			selected[i].Explanation != result.Explanation() {
+			t.Errorf("entry 2 result %d = %+v, want %d, %v, %+v", i, selected[i], result.Instance(), result.Fraction(), result.Explanation())
+		}
+	}
+
+	if err := transcript.Verify(); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+
+	// A transcript survives a JSON round trip and still matches.
+	data, err := json.Marshal(&transcript)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	var loaded Transcript
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if err := loaded.Verify(); err != nil {
+		t.Errorf("Verify() after JSON round trip error = %v", err)
+	}
+	if diffs := transcript.Diff(&loaded); len(diffs) != 0 {
+		t.Errorf("Diff() after JSON round trip = %v", diffs)
+	}
+
+	// Tampering with the consumed bytes is detected.
+	loaded.Entries[1].Bytes[0] ^= 0xff
+	if err := loaded.Verify(); err == nil {
+		t.Error("Verify() accepted tampered bytes")
+	}
+	if diffs := transcript.Diff(&loaded); len(diffs) != 1 {
+		t.Errorf("Diff() = %v, want one difference", diffs)
+	}
+}
+
+func TestTranscriptDiff(t *testing.T) {
+	var a, b Transcript
+	ra := NewRandomness(BetaBytes("test"), WithTranscript(&a))
+	rb := NewRandomness(BetaBytes("test"), WithTranscript(&b))
+
+	ra.Pick(2, 10)
+	ra.Uint8()
+	rb.Pick(2, 10)
+	rb.Uint16()
+	rb.Uint8()
+
+	diffs := a.Diff(&b)
+	if len(diffs) != 5 {
+		t.Errorf("Diff() = %v, want method, range, bytes, value and missing entry differences", diffs)
+	}
+}
+
+// legacyTranscript was recorded before items were encoded with their keys in
+// a fixed order, when a GenericItem was encoded with its keys sorted.
+const legacyTranscript = `{"beta":"74657374","algorithm":"v1","amplifier":"sha512","sampling":"accumulator","stream":"bytes","entries":[{"method":"Selection","offset":0,"length":16,"bytes":"746573743c986704c87a71db3e695892","value":[{"item":{"supply":2,"value":"b","weight":2.5},"instance":1,"fraction":0.3458882655959537,"explanation":{"probability":0.5882352941176471,"itemWeight":5,"totalWeight":8.5,"roll":0.45467301929292747,"low":0.3529411764705882,"high":0.9411764705882353}},{"item":{"supply":2,"value":"b","weight":2.5},"instance":2,"fraction":0.6794840607291804,"explanation":{"probability":0.4166666666666667,"itemWeight":2.5,"totalWeight":6,"roll":0.7831183586371585,"low":0.5,"high":0.9166666666666667}}]}]}`
+
+// itemOnlyTranscript was recorded before selection results were encoded
+// with their instance, fraction and explanation.
+const itemOnlyTranscript = `{"beta":"74657374","algorithm":"v1","amplifier":"sha512","sampling":"accumulator","stream":"bytes","entries":[{"method":"Selection","offset":0,"length":16,"bytes":"746573743c986704c87a71db3e695892","value":[{"Item":{"supply":2,"value":"b","weight":2.5}},{"Item":{"supply":2,"value":"b","weight":2.5}}]}]}`
+
+func TestTranscriptDiffLegacy(t *testing.T) {
+	var transcript Transcript
+	r := NewRandomness(BetaBytes("test"), WithTranscript(&transcript))
+	if _, err := r.Selection(SelectionConfig{Items: goldenItems(), Count: 2}); err != nil {
+		t.Fatalf("Selection() error = %v", err)
+	}
+	data, err := json.Marshal(&transcript)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if string(data) == legacyTranscript {
+		t.Fatal("the item encoding no longer differs from the legacy encoding")
+	}
+
+	for _, tt := range []struct {
+		name  string
+		data  string
+		diffs int
+	}{
+		{"legacy item order", legacyTranscript, 0},
+		{"item only results", itemOnlyTranscript, 1}, // The value of the selection
+	} {
+		var legacy Transcript
+		if err := json.Unmarshal([]byte(tt.data), &legacy); err != nil {
+			t.Fatalf("json.Unmarshal() %s error = %v", tt.name, err)
+		}
+		if err := legacy.Verify(); err != nil {
+			t.Errorf("Verify() %s error = %v", tt.name, err)
+		}
+		if diffs := transcript.Diff(&legacy); len(diffs) != tt.diffs {
+			t.Errorf("Diff() with %s = %v, want %d differences", tt.name, diffs, tt.diffs)
+		}
+	}
+}
+
+func TestTranscriptVerifyOptions(t *testing.T) {
+	var transcript Transcript
+	r, _ := NewRandomnessV(AlgorithmV2, BetaBytes("test"), WithSampling(SamplingAccumulator), WithStream(StreamBits),
+		WithTranscript(&transcript))
+	r.Bits(3)
+	r.IntN(6)
+	r.Uint16()
+	if transcript.Sampling != "accumulator" || transcript.Stream != "bits" {
+		t.Fatalf("transcript header = %s/%s, want accumulator/bits", transcript.Sampling, transcript.Stream)
+	}
+	if err := transcript.Verify(); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+
+	for _, tamper := range []func(*Transcript){
+		func(t *Transcript) { t.Sampling = "modulo" },
+		func(t *Transcript) { t.Stream = "nibbles" },
+	} {
+		tampered := transcript
+		tamper(&tampered)
+		if err := tampered.Verify(); err == nil {
+			t.Errorf("Verify() accepted sampling %q and stream %q", tampered.Sampling, tampered.Stream)
+		}
+	}
+
+	if s, err := ParseSampling("rejection"); err != nil || s != SamplingRejection {
+		t.Errorf("ParseSampling(rejection) = %s, %v", s, err)
+	}
+	if s, err := ParseStream("bytes"); err != nil || s != StreamBytes {
+		t.Errorf("ParseStream(bytes) = %s, %v", s, err)
+	}
+}
+
+func TestTranscriptVerifyOutOfOrder(t *testing.T) {
+	var transcript Transcript
+	r := NewRandomness(BetaBytes("test"), WithTranscript(&transcript))
+	for _, n := range []int{100, 50, 9850, 8} {
+		if _, err := r.Bytes(n); err != nil {
+			t.Fatalf("Bytes() error = %v", err)
+		}
+	}
+
+	// An entry far into the stream followed by one that has been compacted
+	// away but lies past the beta.
+	transcript.Entries = []TranscriptEntry{transcript.Entries[3], transcript.Entries[1], transcript.Entries[3]}
+	if err := transcript.Verify(); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+}
+
+func TestTranscriptValuesAreSnapshots(t *testing.T) {
+	var transcript Transcript
+	r := NewRandomness(BetaBytes("test"), WithTranscript(&transcript))
+
+	deck := []int{0, 1, 2, 3, 4, 5, 6, 7}
+	if err := Shuffle(r, deck); err != nil {
+		t.Fatalf("Shuffle() error = %v", err)
+	}
+	shuffled, _ := json.Marshal(deck)
+	picked, err := r.Pick(3, 10)
+	if err != nil {
+		t.Fatalf("Pick() error = %v", err)
+	}
+	want, _ := json.Marshal(picked)
+
+	// Changing the results after they were recorded leaves the entries alone.
+	if err := Shuffle(r, deck); err != nil {
+		t.Fatalf("Shuffle() error = %v", err)
+	}
+	picked[0] = -1
+
+	if got := string(transcript.Entries[0].Value); got != string(shuffled) {
+		t.Errorf("Shuffle entry = %s after shuffling again, want %s", got, shuffled)
+	}
+	if got := string(transcript.Entries[1].Value); got != string(want) {
+		t.Errorf("Pick entry = %s after editing the result, want %s", got, want)
+	}
+}
+
+func TestTranscriptNumbersValue(t *testing.T) {
+	var transcript Transcript
+	r := NewRandomness(BetaBytes("test"), WithTranscript(&transcript))
+	numbers, err := r.Numbers(5, 37)
+	if err != nil {
+		t.Fatalf("Numbers() error = %v", err)
+	}
+	values, err := numbers.Readn(5, 37)
+	if err != nil {
+		t.Fatalf("Readn() error = %v", err)
+	}
+	want, _ := json.Marshal(map[string]any{"magnitude": 37, "values": values})
+	if got := string(transcript.Entries[0].Value); got != string(want) {
+		t.Errorf("Numbers entry = %s, want %s", got, want)
+	}
+}